@@ -1,84 +1,260 @@
+// Package bitonicsort provides a parallel bitonic sort implementation to sort
+// arrays of any ordered type.
+//
+// Bitonic sort is not stable: equal entries may be reordered.
 package bitonicsort
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
 	"sync"
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
+	"github.com/carlosgvaso/parallel-sort/workers"
 )
 
-//Adding boolean for Ascending and descending order
+// Adding boolean for Ascending and descending order
 const (
 	ASC  bool = true
 	DESC bool = false
 )
 
+// ErrInvalidPadding is returned by SortE when the padding described by diff is
+// not the diff smallest values of the array.
+var ErrInvalidPadding = errors.New("bitonicsort: invalid padding")
+
+// Options configures SortWithOptions.
+type Options struct {
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0 or less, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
+
+	// Descending sorts the array in descending order instead of ascending.
+	Descending bool
+}
+
+func init() {
+	sorter.Register("bitonicsort", sorter.Func(SortContext))
+	sorter.RegisterBaseline("bitonicsort", sorter.Sequential(SortSequential))
+}
+
 // Sort sorts an array in place using the parallel bitonic sort algorithm.
+//
+// The array can be of any length, so zero-padding it with CheckAndAppendZeros
+// is not required. If it was padded, diff is the number of zeros appended, and
+// they are removed from the front of the sorted array. Otherwise, diff is 0.
 func Sort(arr []int, diff int) []int {
-	orderby := true
-	bitonicSort(arr, orderby)
+	arr = SortOrdered(arr)
 	arr = arr[diff:]
 	return arr
 }
 
+// SortContext sorts an array of any length in place using the parallel bitonic
+// sort algorithm, but it stops sorting when ctx is done.
+//
+// Cancellation is checked before each recursive sort and merge step, and no
+// more goroutines are started after ctx is done. In that case, it returns
+// ctx.Err() and the array is left partially sorted.
+func SortContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortWithOptions(ctx, arr, Options{})
+}
+
+// SortWithOptions is like SortContext, but it is configured by opts.
+func SortWithOptions(ctx context.Context, arr []int, opts Options) ([]int, error) {
+	return SortOrderedWithOptions(ctx, arr, opts)
+}
+
+// SortOrdered sorts an array of any ordered type and length in place using the
+// parallel bitonic sort algorithm.
+//
+// It returns the input array sorted.
+func SortOrdered[T cmp.Ordered](arr []T) []T {
+	arr, _ = SortOrderedWithOptions(context.Background(), arr, Options{})
+	return arr
+}
+
+// SortOrderedWithOptions is like SortWithOptions, but for arrays of any
+// ordered type.
+func SortOrderedWithOptions[T cmp.Ordered](ctx context.Context, arr []T, opts Options) ([]T, error) {
+	return SortFuncWithOptions(ctx, arr, cmp.Less[T], opts)
+}
+
+// SortFunc sorts an array of any type in place using the parallel bitonic sort
+// algorithm.
+//
+// less reports whether a sorts before b. It must be a strict weak ordering, and
+// it is called from multiple goroutines at the same time.
+// It returns the input array sorted.
+func SortFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortFuncWithOptions is like SortWithOptions, but for arrays of any type
+// sorted by less.
+func SortFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool, opts Options) ([]T, error) {
+	orderby := ASC
+	if opts.Descending {
+		orderby = DESC
+	}
+
+	bitonicSort(ctx, workers.New(opts.MaxProcs), arr, orderby, less)
+	return arr, ctx.Err()
+}
+
+// SortE is like Sort, but it validates the padding first.
+//
+// It returns ErrInvalidPadding if diff is out of range, the last diff entries
+// of arr are not zeros, or there are negative integers in arr (which would be
+// sorted before the padding, and removed instead of it). In all cases, arr is
+// left unchanged.
+func SortE(arr []int, diff int) ([]int, error) {
+	n := len(arr)
+
+	if diff < 0 || diff > n {
+		return arr, fmt.Errorf("%w: diff = %d, want in [0, %d]", ErrInvalidPadding, diff, n)
+	}
+
+	if diff > 0 {
+		for i := n - diff; i < n; i++ {
+			if arr[i] != 0 {
+				return arr, fmt.Errorf("%w: padding entry %d is %d, want 0", ErrInvalidPadding, i, arr[i])
+			}
+		}
+		for i, v := range arr {
+			if v < 0 {
+				return arr, fmt.Errorf("%w: negative value %d at index %d sorts before the padding",
+					ErrInvalidPadding, v, i)
+			}
+		}
+	}
+
+	return Sort(arr, diff), nil
+}
+
 // CheckAndAppendZeros updates the array by making its length exponential of 2 by appending 0's
 // returns the array and difference of updated and acutal size
-func CheckAndAppendZeros(arr []int) ([]int, int) {
+//
+// The zero value of T is used as the padding.
+func CheckAndAppendZeros[T cmp.Ordered](arr []T) ([]T, int) {
 	i := 2
 	size := len(arr)
 	for size > i {
 		i *= 2
 	}
 	i = i - size
-	var a = make([]int, i)
+	var a = make([]T, i)
 	arr = append(arr, a...)
 	return arr, i
 }
 
 // bitonicSort  will return the sorted array based on the input array
-func bitonicSort(arr []int, orderby bool) {
-	if len(arr) < 2 {
+//
+// The first half is sorted in the opposite order to the second half, so the
+// array is a bitonic sequence before merging. This works for any array length.
+func bitonicSort[T any](ctx context.Context, pool *workers.Pool, arr []T, orderby bool, less func(a, b T) bool) {
+	if len(arr) < 2 || ctx.Err() != nil {
 		return
 	}
 
 	middle := len(arr) / 2
 	var wg sync.WaitGroup
-	wg.Add(2)
 
-	go func() {
-		defer wg.Done()
-		bitonicSort(arr[:middle], ASC)
-	}()
-
-	go func() {
-		defer wg.Done()
-		bitonicSort(arr[middle:], DESC)
-	}()
+	pool.Go(&wg, func() {
+		bitonicSort(ctx, pool, arr[:middle], !orderby, less)
+	})
+	bitonicSort(ctx, pool, arr[middle:], orderby, less)
 	wg.Wait()
-	bitonicMerge(arr, orderby)
+	bitonicMerge(ctx, pool, arr, orderby, less)
 }
 
-func bitonicCompare(arr []int, orderby bool) {
-	middle := len(arr) / 2
-	for i := 0; i < middle; i++ {
-		if (arr[i] > arr[i+middle]) == orderby {
+// bitonicCompare compares each entry i in the first len(arr)-middle entries of
+// the array with the entry i+middle, and swaps them if they are not in order.
+// Middle is at least half the length, so every entry from index middle on has
+// a partner, and the entries between len(arr)-middle and middle are left as is.
+func bitonicCompare[T any](arr []T, middle int, orderby bool, less func(a, b T) bool) {
+	for i := 0; i < len(arr)-middle; i++ {
+		var outOfOrder bool
+		if orderby == ASC {
+			outOfOrder = less(arr[i+middle], arr[i])
+		} else {
+			outOfOrder = less(arr[i], arr[i+middle])
+		}
+
+		if outOfOrder {
 			arr[i], arr[i+middle] = arr[i+middle], arr[i]
 		}
 	}
 }
 
-func bitonicMerge(arr []int, orderby bool) {
-	bitonicCompare(arr, orderby)
-	middle := len(arr) / 2
-	if middle > 1 {
+// bitonicMerge sorts a bitonic sequence of any length.
+//
+// The split point is the greatest power of 2 less than the length, so the
+// first part is a power of 2 length bitonic sequence, and every entry of one
+// part is in order with every entry of the other after the compare step.
+func bitonicMerge[T any](ctx context.Context, pool *workers.Pool, arr []T, orderby bool, less func(a, b T) bool) {
+	if len(arr) < 2 || ctx.Err() != nil {
+		return
+	}
+
+	middle := greatestPowerOfTwoLessThan(len(arr))
+	bitonicCompare(arr, middle, orderby, less)
+	if len(arr) > 2 {
 		var wg sync.WaitGroup
-		wg.Add(2)
-		go func() {
-			defer wg.Done()
-			bitonicMerge(arr[:middle], orderby)
-		}()
-		go func() {
-			defer wg.Done()
-			bitonicMerge(arr[middle:], orderby)
-		}()
+		pool.Go(&wg, func() {
+			bitonicMerge(ctx, pool, arr[:middle], orderby, less)
+		})
+		bitonicMerge(ctx, pool, arr[middle:], orderby, less)
 		wg.Wait()
 
 	}
 }
+
+/* Sequential */
+
+// SortSequential sorts an array of any length in place using the sequential
+// bitonic sort algorithm on a single goroutine.
+//
+// It is the baseline to measure the speedup of SortContext.
+// It returns the input array sorted.
+func SortSequential(arr []int) []int {
+	sequentialBitonicSort(arr, ASC, cmp.Less[int])
+	return arr
+}
+
+// SequentialBitonicSort is like bitonicSort, but it sorts both halves in the
+// calling goroutine.
+func sequentialBitonicSort[T any](arr []T, orderby bool, less func(a, b T) bool) {
+	if len(arr) < 2 {
+		return
+	}
+
+	middle := len(arr) / 2
+	sequentialBitonicSort(arr[:middle], !orderby, less)
+	sequentialBitonicSort(arr[middle:], orderby, less)
+	sequentialBitonicMerge(arr, orderby, less)
+}
+
+// SequentialBitonicMerge is like bitonicMerge, but it merges both parts in the
+// calling goroutine.
+func sequentialBitonicMerge[T any](arr []T, orderby bool, less func(a, b T) bool) {
+	if len(arr) < 2 {
+		return
+	}
+
+	middle := greatestPowerOfTwoLessThan(len(arr))
+	bitonicCompare(arr, middle, orderby, less)
+	sequentialBitonicMerge(arr[:middle], orderby, less)
+	sequentialBitonicMerge(arr[middle:], orderby, less)
+}
+
+// greatestPowerOfTwoLessThan returns the greatest power of 2 less than n > 1.
+func greatestPowerOfTwoLessThan(n int) int {
+	k := 1
+	for k < n {
+		k <<= 1
+	}
+	return k >> 1
+}
@@ -2,7 +2,11 @@
 package bitonicsort
 
 import (
+	"context"
+	"errors"
+	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -33,3 +37,152 @@ func TestSort(t *testing.T) {
 		}
 	}
 }
+
+// TestSortAnyLength checks Sort with input arrays that are not zero-padded.
+func TestSortAnyLength(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{}, []int{}},
+		{[]int{1}, []int{1}},
+		{[]int{2, -1, 0}, []int{-1, 0, 2}},
+		{[]int{7, 6, 5, 4, 3, 9, 2, 1, 0, 8}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{[]int{5, -3, 5, 0, -3, 12, 1}, []int{-3, -3, 0, 1, 5, 5, 12}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got := Sort(arrIn, 0)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Sort (%v, 0) == %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+// TestSortE checks SortE rejects invalid padding.
+func TestSortE(t *testing.T) {
+	cases := []struct {
+		in      []int
+		diff    int
+		wantErr error
+	}{
+		{[]int{3, 1, 2, 0}, 1, nil},
+		{[]int{3, 1, 2, 0}, 5, ErrInvalidPadding},
+		{[]int{3, 1, 2, 0}, -1, ErrInvalidPadding},
+		{[]int{3, 1, 0, 2}, 2, ErrInvalidPadding},
+		{[]int{3, -1, 2, 0}, 1, ErrInvalidPadding},
+		{[]int{3, -1, 2}, 0, nil},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		_, err := SortE(arrIn, c.diff)
+
+		if !errors.Is(err, c.wantErr) {
+			t.Errorf("SortE (%v, %d) returned error %v, want %v", c.in, c.diff, err, c.wantErr)
+		}
+	}
+}
+
+// TestSortContext checks SortContext stops sorting when the context is done.
+func TestSortContext(t *testing.T) {
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	arrIn := make([]int, len(in))
+	copy(arrIn, in)
+	if _, err := SortContext(ctx, arrIn); err != context.Canceled {
+		t.Errorf("SortContext (canceled, %v) returned error %v, want %v", in, err, context.Canceled)
+	}
+
+	copy(arrIn, in)
+	got, err := SortContext(context.Background(), arrIn)
+	if err != nil {
+		t.Errorf("SortContext (%v) returned error %v", in, err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortContext (%v) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortOrdered checks SortOrdered with arrays of other ordered types.
+func TestSortOrdered(t *testing.T) {
+	floats := SortOrdered([]float64{2.5, -1, 0, 3.25, -7.5, 2.5})
+	if want := []float64{-7.5, -1, 0, 2.5, 2.5, 3.25}; !reflect.DeepEqual(floats, want) {
+		t.Errorf("SortOrdered ([]float64) == %v, want %v", floats, want)
+	}
+
+	strs := SortOrdered([]string{"pear", "apple", "fig", "", "banana"})
+	if want := []string{"", "apple", "banana", "fig", "pear"}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("SortOrdered ([]string) == %v, want %v", strs, want)
+	}
+
+	uints := SortOrdered([]uint64{1 << 63, 7, 0, 1<<64 - 1, 42})
+	if want := []uint64{0, 7, 42, 1 << 63, 1<<64 - 1}; !reflect.DeepEqual(uints, want) {
+		t.Errorf("SortOrdered ([]uint64) == %v, want %v", uints, want)
+	}
+}
+
+// TestSortFunc checks SortFunc with an array of records.
+func TestSortFunc(t *testing.T) {
+	type record struct {
+		name string
+		age  int
+	}
+	in := []record{{"carol", 35}, {"alice", 30}, {"dave", 20}, {"bob", 25}, {"erin", 40}}
+	want := []record{{"dave", 20}, {"bob", 25}, {"alice", 30}, {"carol", 35}, {"erin", 40}}
+
+	got := SortFunc(in, func(a, b record) bool { return a.age < b.age })
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortFunc (%v, by age) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortDescending checks SortWithOptions sorts in descending order.
+func TestSortDescending(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{7, 6, 5, 4, 3, 2, 1, 0}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4, 5}, []int{7, 6, 5, 5, 4, 3, 2, 1, 0}},
+		{[]int{42, 7, 999, 0, 130}, []int{999, 130, 42, 7, 0}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := SortWithOptions(context.Background(), arrIn, Options{Descending: true})
+
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortWithOptions (%v, descending) == %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+// TestSortSequential checks the sequential baseline sorts like Sort.
+func TestSortSequential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 7, 100, 1000} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(n + 1)
+		}
+		want := make([]int, n)
+		copy(want, in)
+		sort.Ints(want)
+
+		if got := SortSequential(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SortSequential (n = %d) == %v, want %v", n, got, want)
+		}
+	}
+}
@@ -1,51 +1,178 @@
 // Package bricksort provides a parallel brick sort implementation to sort
-// integer arrays.
+// arrays of any ordered type.
+//
+// Brick sort is stable: it only swaps adjacent entries that are out of order,
+// so equal entries keep their relative order.
 package bricksort
 
 import (
-	"sync"
+	"cmp"
+	"context"
+	"sync/atomic"
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
+	"github.com/carlosgvaso/parallel-sort/workers"
 )
 
+// Grain is the minimum number of comparisons each goroutine does in a phase.
+const grain int = 1 << 10
+
+// Options configures SortWithOptions.
+type Options struct {
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0 or less, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
+
+	// Descending sorts the array in descending order instead of ascending.
+	Descending bool
+}
+
+func init() {
+	sorter.Register("bricksort", sorter.Func(SortContext))
+	sorter.RegisterBaseline("bricksort", sorter.Sequential(SortSequential))
+}
+
 // Sort sorts an array in place using the parallel brick sort algorithm.
 //
 // It takes an array as an input.
 // It returns the input array sorted.
 func Sort(arr []int) []int {
-	var waitGroup sync.WaitGroup // Wait group to synchronize parallel goroutines
-	var isSorted bool = false    // True if there weren't any swaps for an iter
-	var n int = len(arr)         // Length of the array
-	var iter int = 0             // Count the iterations it takes to get sorted
+	return SortOrdered(arr)
+}
+
+// SortContext is like Sort, but it stops sorting when ctx is done.
+//
+// Cancellation is checked before each odd and even phase. In that case, it
+// returns ctx.Err() and the array is left partially sorted.
+func SortContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortWithOptions(ctx, arr, Options{})
+}
+
+// SortWithOptions is like SortContext, but it is configured by opts.
+//
+// The comparisons of each phase are split in chunks, and each chunk is run by
+// a goroutine from the pool.
+func SortWithOptions(ctx context.Context, arr []int, opts Options) ([]int, error) {
+	return SortOrderedWithOptions(ctx, arr, opts)
+}
+
+// SortOrdered sorts an array of any ordered type in place using the parallel
+// brick sort algorithm.
+//
+// It returns the input array sorted.
+func SortOrdered[T cmp.Ordered](arr []T) []T {
+	arr, _ = SortOrderedWithOptions(context.Background(), arr, Options{})
+	return arr
+}
+
+// SortOrderedWithOptions is like SortWithOptions, but for arrays of any
+// ordered type.
+func SortOrderedWithOptions[T cmp.Ordered](ctx context.Context, arr []T, opts Options) ([]T, error) {
+	return SortFuncWithOptions(ctx, arr, cmp.Less[T], opts)
+}
+
+// SortFunc sorts an array of any type in place using the parallel brick sort
+// algorithm.
+//
+// less reports whether a sorts before b. It must be a strict weak ordering, and
+// it is called from multiple goroutines at the same time.
+// It returns the input array sorted.
+func SortFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortFuncWithOptions is like SortWithOptions, but for arrays of any type
+// sorted by less.
+func SortFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool, opts Options) ([]T, error) {
+	var pool = workers.New(opts.MaxProcs) // Goroutine budget
+	var isSorted bool = false             // True if there weren't any swaps for an iter
+	var iter int = 0                      // Count the iterations it takes to get sorted
+
+	// Sort in descending order by swapping the arguments of less
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
 
 	// Iterate until the array is sorted (max n iters)
 	for isSorted == false {
 		iter++
-		isSorted = true // Assume is sorted, and set false if there is a swap
 
-		for i := 1; i < n; i += 2 {
-			waitGroup.Add(1)
-			go swap(arr, i, &isSorted, &waitGroup)
+		if err := ctx.Err(); err != nil {
+			return arr, err
 		}
-		waitGroup.Wait()
+		oddSorted := phase(pool, arr, 1, less)
+
+		if err := ctx.Err(); err != nil {
+			return arr, err
+		}
+		evenSorted := phase(pool, arr, 2, less)
+
+		isSorted = oddSorted && evenSorted
+	}
+
+	return arr, nil
+}
 
-		for i := 2; i < n; i += 2 {
-			waitGroup.Add(1)
-			go swap(arr, i, &isSorted, &waitGroup)
+/* Sequential */
+
+// SortSequential sorts an array in place using the sequential brick sort
+// algorithm on a single goroutine.
+//
+// It is the baseline to measure the speedup of Sort.
+// It returns the input array sorted.
+func SortSequential(arr []int) []int {
+	less := cmp.Less[int]
+
+	for isSorted := false; !isSorted; {
+		isSorted = true
+		for start := 1; start <= 2; start++ {
+			for i := start; i < len(arr); i += 2 {
+				if swap(arr, i, less) {
+					isSorted = false
+				}
+			}
 		}
-		waitGroup.Wait()
 	}
 
 	return arr
 }
 
-// Swap checks if arr[i-1] > arr[i], and swaps their values and sets isSorted to
-// false if true. It does nothing otherwise.
-func swap(arr []int, i int, isSorted *bool, waitGroup *sync.WaitGroup) {
-	defer waitGroup.Done()
+// Phase runs swap on arr[i-1] and arr[i] for i = start, start+2, ... < n in
+// parallel.
+//
+// It returns true if there weren't any swaps.
+func phase[T any](pool *workers.Pool, arr []T, start int, less func(a, b T) bool) bool {
+	var swapped int32 // Set to 1 if there is a swap
+
+	// Number of comparisons in this phase
+	pairs := (len(arr) - start + 1) / 2
 
-	if arr[i-1] > arr[i] {
+	pool.For(pairs, grain, func(lo, hi int) {
+		chunkSwapped := false
+		for j := lo; j < hi; j++ {
+			if swap(arr, start+2*j, less) {
+				chunkSwapped = true
+			}
+		}
+		if chunkSwapped {
+			atomic.StoreInt32(&swapped, 1)
+		}
+	})
+
+	return atomic.LoadInt32(&swapped) == 0
+}
+
+// Swap checks if arr[i] sorts before arr[i-1], and swaps their values and
+// returns true if true. It returns false otherwise.
+func swap[T any](arr []T, i int, less func(a, b T) bool) bool {
+	if less(arr[i], arr[i-1]) {
 		tmp := arr[i]
 		arr[i] = arr[i-1]
 		arr[i-1] = tmp
-		*isSorted = false
+		return true
 	}
+
+	return false
 }
@@ -2,8 +2,13 @@
 package bricksort
 
 import (
+	"context"
+	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
 )
 
 // TestSort checks Sort with a multitude of input arrays.
@@ -29,3 +34,119 @@ func TestSort(t *testing.T) {
 		}
 	}
 }
+
+// TestSortContext checks SortContext stops sorting when the context is done.
+func TestSortContext(t *testing.T) {
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	arrIn := make([]int, len(in))
+	copy(arrIn, in)
+	if _, err := SortContext(ctx, arrIn); err != context.Canceled {
+		t.Errorf("SortContext (canceled, %v) returned error %v, want %v", in, err, context.Canceled)
+	}
+
+	copy(arrIn, in)
+	got, err := SortContext(context.Background(), arrIn)
+	if err != nil {
+		t.Errorf("SortContext (%v) returned error %v", in, err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortContext (%v) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortOrdered checks SortOrdered with arrays of other ordered types.
+func TestSortOrdered(t *testing.T) {
+	floats := SortOrdered([]float64{2.5, -1, 0, 3.25, -7.5, 2.5})
+	if want := []float64{-7.5, -1, 0, 2.5, 2.5, 3.25}; !reflect.DeepEqual(floats, want) {
+		t.Errorf("SortOrdered ([]float64) == %v, want %v", floats, want)
+	}
+
+	strs := SortOrdered([]string{"pear", "apple", "fig", "", "banana"})
+	if want := []string{"", "apple", "banana", "fig", "pear"}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("SortOrdered ([]string) == %v, want %v", strs, want)
+	}
+
+	uints := SortOrdered([]uint64{1 << 63, 7, 0, 1<<64 - 1, 42})
+	if want := []uint64{0, 7, 42, 1 << 63, 1<<64 - 1}; !reflect.DeepEqual(uints, want) {
+		t.Errorf("SortOrdered ([]uint64) == %v, want %v", uints, want)
+	}
+}
+
+// TestSortFunc checks SortFunc with an array of records.
+func TestSortFunc(t *testing.T) {
+	type record struct {
+		name string
+		age  int
+	}
+	in := []record{{"carol", 35}, {"alice", 30}, {"dave", 20}, {"bob", 25}, {"erin", 40}}
+	want := []record{{"dave", 20}, {"bob", 25}, {"alice", 30}, {"carol", 35}, {"erin", 40}}
+
+	got := SortFunc(in, func(a, b record) bool { return a.age < b.age })
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortFunc (%v, by age) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortStable checks SortFunc keeps the payloads of entries with equal keys in
+// their original order.
+func TestSortStable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 10, 1000, 3000} {
+		in := stabletest.Pairs(r, n, 16)
+
+		got, err := SortFuncWithOptions(context.Background(), in, stabletest.Less, Options{MaxProcs: 4})
+		if err == nil {
+			err = stabletest.Check(got, false)
+		}
+		if err != nil {
+			t.Errorf("SortFunc (n = %d): %v", n, err)
+		}
+	}
+}
+
+// TestSortDescending checks SortWithOptions sorts in descending order.
+func TestSortDescending(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{7, 6, 5, 4, 3, 2, 1, 0}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4, 5}, []int{7, 6, 5, 5, 4, 3, 2, 1, 0}},
+		{[]int{42, 7, 999, 0, 130}, []int{999, 130, 42, 7, 0}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := SortWithOptions(context.Background(), arrIn, Options{Descending: true})
+
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortWithOptions (%v, descending) == %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+// TestSortSequential checks the sequential baseline sorts like Sort.
+func TestSortSequential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 7, 100, 1000} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(n + 1)
+		}
+		want := make([]int, n)
+		copy(want, in)
+		sort.Ints(want)
+
+		if got := SortSequential(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SortSequential (n = %d) == %v, want %v", n, got, want)
+		}
+	}
+}
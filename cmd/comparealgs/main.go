@@ -3,23 +3,31 @@
 package main
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/carlosgvaso/parallel-sort/bitonicsort"
-	"github.com/carlosgvaso/parallel-sort/bricksort"
-	"github.com/carlosgvaso/parallel-sort/mergesort"
-	"github.com/carlosgvaso/parallel-sort/quicksort"
-	"github.com/carlosgvaso/parallel-sort/radixsort"
+	"github.com/carlosgvaso/parallel-sort/sorter"
+
+	// Register the sorting algorithms
+	_ "github.com/carlosgvaso/parallel-sort/bitonicsort"
+	_ "github.com/carlosgvaso/parallel-sort/bricksort"
+	_ "github.com/carlosgvaso/parallel-sort/mergesort"
+	_ "github.com/carlosgvaso/parallel-sort/quicksort"
+	_ "github.com/carlosgvaso/parallel-sort/radixsort"
+	_ "github.com/carlosgvaso/parallel-sort/timsort"
 )
 
 // OutFile is the output file's path.
@@ -39,6 +47,9 @@ var runs int = 1000
 // SleepTime is the time in seconds to sleep between runs to let the CPUs cool down.
 var sleepTime time.Duration = 5
 
+// Timeout is the maximum time each run is allowed to take (0 for no timeout).
+var timeout time.Duration = 0
+
 // FreeProcs is the number of processor cores to leave free (not use in parallel).
 var freeProcs int = 2
 
@@ -75,21 +86,43 @@ func (i *arrayFlags) Set(value string) error {
 	return nil
 }
 
-// LoadArrays loads the arrIn and arrOut arrays in parallel with the provided
-// value val converted to an int.
-func loadArrays(val string, arrIn []int, arrOut []int, i int,
-	waitGroup *sync.WaitGroup) {
-	defer waitGroup.Done()
+// ErrUnknownFormat is returned when reading an input file in an unknown format.
+var errUnknownFormat = errors.New("unknown input file format")
+
+// LoadArrays loads the arrIn and arrOut arrays with the provided value val
+// converted to an int.
+//
+// It returns an error if val could not be parsed.
+func loadArrays(val string, arrIn []int, arrOut []int, i int) error {
 	var err error
 
 	// Convert val from string to int, and save it to arrIn
 	arrIn[i], err = strconv.Atoi(val)
 	if err != nil {
-		log.Fatalln("Could not parse the input array", err)
+		return fmt.Errorf("could not parse entry %d of the input array: %w", i, err)
 	}
 
 	// Copy arrIn to arrOut
 	arrOut[i] = arrIn[i]
+
+	return nil
+}
+
+// ReadInput reads the input file in the provided format.
+//
+// Formats are: 0 for CSV, 1 for array entry per line.
+//
+// It returns the same values as readInputCsv and readInputEntryPerLine, or an
+// error if the format is unknown or the file could not be read.
+func readInput(inFile string, format int) ([]int, []int, int, error) {
+	switch format {
+	case 0:
+		return readInputCsv(inFile)
+	case 1:
+		return readInputEntryPerLine(inFile)
+	default:
+		return nil, nil, 0, fmt.Errorf("%w: %d", errUnknownFormat, format)
+	}
 }
 
 // ReadInputCsv reads the input file.
@@ -98,13 +131,14 @@ func loadArrays(val string, arrIn []int, arrOut []int, i int,
 //
 // It returns arrays arrIn and arrOut with the comma-separated entries in the
 // first line of the file converted to integers, and integer n with the length
-// of the arrays.
-func readInputCsv(inFile string) ([]int, []int, int) {
+// of the arrays. It returns an error if the file could not be read or parsed.
+func readInputCsv(inFile string) ([]int, []int, int, error) {
 	// Open the file
 	csvfile, err := os.Open(inFile)
 	if err != nil {
-		log.Fatalln("Could not open the input file", err)
+		return nil, nil, 0, fmt.Errorf("could not open the input file: %w", err)
 	}
+	defer csvfile.Close()
 
 	// Parse the file
 	r := csv.NewReader(csvfile)
@@ -112,12 +146,9 @@ func readInputCsv(inFile string) ([]int, []int, int) {
 	// Read first line only
 	record, err := r.Read()
 	if err != nil {
-		log.Fatalln("Could not read the input file", err)
+		return nil, nil, 0, fmt.Errorf("could not read the input file: %w", err)
 	}
 
-	// Close file
-	csvfile.Close()
-
 	// Setup in/out arrays
 	var n int = len(record)
 	arrIn := make([]int, n)
@@ -128,13 +159,29 @@ func readInputCsv(inFile string) ([]int, []int, int) {
 	// some methods sort the array in place. In those cases, we pass the arrOut
 	// as the input.
 	var waitGroup sync.WaitGroup // Wait group to synchronize parallel goroutines
+	var errLock sync.Mutex       // Lock to save the first parsing error
+	var loadErr error            // First parsing error
 	for i, v := range record {
 		waitGroup.Add(1)
-		go loadArrays(v, arrIn, arrOut, i, &waitGroup)
+		go func(i int, v string) {
+			defer waitGroup.Done()
+
+			if err := loadArrays(v, arrIn, arrOut, i); err != nil {
+				errLock.Lock()
+				if loadErr == nil {
+					loadErr = err
+				}
+				errLock.Unlock()
+			}
+		}(i, v)
 	}
 	waitGroup.Wait()
 
-	return arrIn, arrOut, n
+	if loadErr != nil {
+		return nil, nil, 0, loadErr
+	}
+
+	return arrIn, arrOut, n, nil
 }
 
 // ReadInputEntryPerLine reads the input file.
@@ -142,15 +189,15 @@ func readInputCsv(inFile string) ([]int, []int, int) {
 // It assumes the file is in a format with a single array integer entry per line
 // of the file.
 //
-// It returns arrays arrIn and arrOut with the comma-separated entries in the
-// first line of the file converted to integers, and integer n with the length
-// of the arrays.
-func readInputEntryPerLine(inFile string) ([]int, []int, int) {
+// It returns arrays arrIn and arrOut with the entries in each line of the file
+// converted to integers, and integer n with the length of the arrays. It
+// returns an error if the file could not be read or parsed.
+func readInputEntryPerLine(inFile string) ([]int, []int, int, error) {
 	var n int = 0
 
 	b, err := ioutil.ReadFile(inFile)
 	if err != nil {
-		log.Fatalln("Could not read the input file", err)
+		return nil, nil, 0, fmt.Errorf("could not read the input file: %w", err)
 	}
 
 	lines := strings.Split(string(b), "\n")
@@ -159,7 +206,7 @@ func readInputEntryPerLine(inFile string) ([]int, []int, int) {
 	arrIn := make([]int, 0, n)
 	arrOut := make([]int, 0, n)
 
-	for _, l := range lines {
+	for i, l := range lines {
 		// Empty line occurs at the end of the file when we use Split.
 		if len(l) == 0 {
 			continue
@@ -168,7 +215,7 @@ func readInputEntryPerLine(inFile string) ([]int, []int, int) {
 		// with
 		num, err := strconv.Atoi(l)
 		if err != nil {
-			log.Fatalln("Could not parse the input array", err)
+			return nil, nil, 0, fmt.Errorf("could not parse line %d of the input file: %w", i+1, err)
 		}
 		arrIn = append(arrIn, num)
 		arrOut = append(arrOut, num)
@@ -176,32 +223,68 @@ func readInputEntryPerLine(inFile string) ([]int, []int, int) {
 
 	n = len(arrIn)
 
-	return arrIn, arrOut, n
+	return arrIn, arrOut, n, nil
 }
 
-// MaxNumDigits gets number of digits of largest integer in array of positive
-// integers.
+// TimeRuns sorts arrOut with s runs+1 times, copying arrIn to arrOut after each
+// run, and returns the execution time of each run but the first, which is
+// always artificially slower.
 //
-// arr is the input array of positive integers.
-// It return the number of digits of the largest integer in array
-func maxNumDigits(arr []int) int {
-	var k int = 0
-	var max int = 0
-
-	// Find the largest integer in array
-	for _, v := range arr {
-		if v > max {
-			max = v
+// Each run is canceled after the timeout, if there is one. It returns the
+// first error returned by s.
+func timeRuns(s sorter.Sorter, arrIn []int, arrOut []int) ([]time.Duration, error) {
+	execTimes := make([]time.Duration, 0, runs)
+
+	for i := 0; i <= runs; i++ {
+		// Sorters sort in place, so pass arrOut to preserve arrIn
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		startTime := time.Now()
+		_, err := s.Sort(ctx, arrOut)
+		execTime := time.Since(startTime)
+		cancel()
+
+		// Copy arrIn to arrOut for the next iteration
+		copy(arrOut, arrIn)
+		if err != nil {
+			return nil, err
 		}
+
+		if i > 0 {
+			fmt.Printf("\t\tExec time %d: %s\n", i, execTime)
+			execTimes = append(execTimes, execTime)
+		}
+
+		// Sleep between runs to let the CPUs cool down
+		time.Sleep(sleepTime * time.Second)
 	}
 
-	// Find the number of characters of the largest integer
-	for max != 0 {
-		max /= 10
-		k++
+	fmt.Printf("\t\tExec time avg: %dns\n", int(average(execTimes)))
+
+	return execTimes, nil
+}
+
+// Average returns the average of the execution times, or 0 if there are none.
+func average(execTimes []time.Duration) time.Duration {
+	if len(execTimes) == 0 {
+		return 0
+	}
+
+	var sum time.Duration
+	for _, t := range execTimes {
+		sum += t
 	}
 
-	return k
+	return sum / time.Duration(len(execTimes))
+}
+
+// PrintTimes writes the execution times to the output file as CSV fields.
+func printTimes(fout io.Writer, execTimes []time.Duration) {
+	for _, t := range execTimes {
+		fmt.Fprintf(fout, "%d,", int(t))
+	}
 }
 
 // Main reads the array in the input file, and records the execution times each
@@ -220,9 +303,10 @@ func main() {
 	inFilePtr := flag.String("input", inFile, "Input file's path")
 	inFileFormatPtr := flag.Int("format", inFileFormat, "Input file's format")
 	outFilePtr := flag.String("output", outFile, "Output file's path")
-	procsPtr := flag.Int("procs", (cores - freeProcs), "Maximum number of CPUs to use in parallel")
+	procsPtr := flag.Int("procs", (cores - freeProcs), "Maximum number of CPUs, and goroutines sorting, to use in parallel")
 	runsPtr := flag.Int("runs", runs, "Number of times each algorithm is run to average execution time")
-	flag.Var(&algs, "alg", "Specify algorithms to run. This flag should be called multiple times for each algorithm to run. Available algorithms are: bitonicsort, bricksort, mergesort, quicksort and radixsort")
+	timeoutPtr := flag.Duration("timeout", timeout, "Maximum time each run is allowed to take (0 for no timeout)")
+	flag.Var(&algs, "alg", "Specify algorithms to run. This flag should be called multiple times for each algorithm to run. Available algorithms are: "+strings.Join(sorter.Names(), ", "))
 	flag.Parse()
 
 	inFile = *inFilePtr
@@ -230,36 +314,30 @@ func main() {
 	outFile = *outFilePtr
 	procs = *procsPtr
 	runs = *runsPtr
+	timeout = *timeoutPtr
 
 	if algs == nil {
-		algs.Set("bitonicsort")
-		algs.Set("bricksort")
-		algs.Set("mergesort")
-		algs.Set("quicksort")
-		algs.Set("radixsort")
+		for _, name := range sorter.Names() {
+			algs.Set(name)
+		}
 	}
 
 	// Read the input file
-	switch inFileFormat {
-	case 0:
-		arrIn, arrOut, n = readInputCsv(inFile)
-	case 1:
-		arrIn, arrOut, n = readInputEntryPerLine(inFile)
-	default:
-		log.Fatalln("Unknown input file format")
+	arrIn, arrOut, n, err = readInput(inFile, inFileFormat)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	// Get number of digits of largest integer in the input array for radix sort
-	k := maxNumDigits(arrIn)
-
 	// Open output file
 	fout, err := os.Create(outFile)
 	if err != nil {
 		log.Fatalln("Could not open the output file", err)
 	}
 
-	// Print problem parameters
+	// Print problem parameters. The sorting algorithms size their goroutine
+	// budget from GOMAXPROCS by default, so this bounds both.
 	runtime.GOMAXPROCS(procs)
+	procs = runtime.GOMAXPROCS(0)
 	fmt.Printf("Input file: %s\nOutput file: %s\nLogical CPUs: %d\nMax procs: %d\nRuns: %d\nProblem size: n = %d\n",
 		inFile, outFile, cores, procs, runs, n)
 	fmt.Fprintf(fout, "Input=%s\nOutput=%s\nTimes measured in nsec\ncores=%d\nmaxProcs=%d\nruns=%d\nn=%d\narrIn=%v\n\n",
@@ -270,199 +348,70 @@ func main() {
 	for i := 1; i <= runs; i++ {
 		fmt.Fprintf(fout, "ExecTime%d,", i)
 	}
-	fmt.Fprintf(fout, "ExecTimeAvg\n")
-
-	// Initialize variables to calculate the execution time averages
-	var execTimeAvg int = 0
+	fmt.Fprintf(fout, "ExecTimeAvg,SeqTimeAvg,Speedup,Efficiency,SpeedupSortInts\n")
+
+	// Time sort.Ints as the sequential baseline for all algorithms
+	fmt.Printf("\tsort.Ints:\n")
+	fmt.Fprintf(fout, "sort.Ints,")
+	sortInts := sorter.Sequential(func(arr []int) []int {
+		sort.Ints(arr)
+		return arr
+	})
+	execTimes, err := timeRuns(sortInts, arrIn, arrOut)
+	if err != nil {
+		log.Fatalln("Could not time sort.Ints", err)
+	}
+	sortIntsAvg := average(execTimes)
+	printTimes(fout, execTimes)
+	fmt.Fprintf(fout, "%d,,,,\n", int(sortIntsAvg))
 
 	for _, alg := range algs {
-		switch alg {
-		case "bitonicsort":
-			// Run bitonic sort
-			fmt.Printf("\tBitonic Sort:\n")
-			fmt.Fprintf(fout, "bitonicsort,")
-
-			// Setup variables to calculate the execution time averages
-			execTimeAvg = 0
-
-			// Run benchmarks
-			for i := 0; i <= runs; i++ {
-				// Append 0's to array to make its length exponential of 2
-				var diff int
-				arrOut, diff = bitonicsort.CheckAndAppendZeros(arrOut)
-
-				// Bitonic sort sorts in place, so pass arrOut to preserve arrIn
-				startTime := time.Now()
-				arrOut = bitonicsort.Sort(arrOut, diff)
-				execTime := time.Since(startTime)
-
-				// Ignore the first run because it is always artificially slower
-				if i > 0 {
-					fmt.Printf("\t\tExec time %d: %s\n", i, execTime)
-					fmt.Fprintf(fout, "%d,", int(execTime))
-
-					// Add all times to average them
-					execTimeAvg += int(execTime)
-				}
-
-				// Copy arrIn to arrOut for the next iteration
-				copy(arrOut, arrIn)
-
-				// Sleep between runs to let the CPUs cool down
-				time.Sleep(sleepTime * time.Second)
-			}
-
-			// Calculate average
-			execTimeAvg = execTimeAvg / runs
-			fmt.Printf("\t\tExec time avg: %dns\n", execTimeAvg)
-			fmt.Fprintf(fout, "%d\n", execTimeAvg)
-
-		case "bricksort":
-			// Run brick sort
-			fmt.Printf("\tBrick Sort:\n")
-			fmt.Fprintf(fout, "bricksort,")
-
-			// Setup variables to calculate the execution time averages
-			execTimeAvg = 0
-
-			// Run benchmarks
-			for i := 0; i <= runs; i++ {
-				// Brick sort sorts in place, so pass arrOut to preserve arrIn
-				startTime := time.Now()
-				arrOut = bricksort.Sort(arrOut)
-				execTime := time.Since(startTime)
-
-				// Ignore the first run because it is always artificially slower
-				if i > 0 {
-					fmt.Printf("\t\tExec time %d: %s\n", i, execTime)
-					fmt.Fprintf(fout, "%d,", int(execTime))
-
-					// Add all times to average them
-					execTimeAvg += int(execTime)
-				}
-
-				// Copy arrIn to arrOut for the next iteration
-				copy(arrOut, arrIn)
-
-				// Sleep between runs to let the CPUs cool down
-				time.Sleep(sleepTime * time.Second)
-			}
-
-			// Calculate average
-			execTimeAvg = execTimeAvg / runs
-			fmt.Printf("\t\tExec time avg: %dns\n", execTimeAvg)
-			fmt.Fprintf(fout, "%d\n", execTimeAvg)
-
-		case "mergesort":
-			// Run mergesort
-			fmt.Printf("\tMergesort:\n")
-			fmt.Fprintf(fout, "mergesort,")
-
-			// Setup variables to calculate the execution time averages
-			execTimeAvg = 0
-
-			// Run benchmarks
-			for i := 0; i <= runs; i++ {
-				// Mergesort sorts in place, so pass arrOut to preserve arrIn
-				startTime := time.Now()
-				arrOut = mergesort.Sort(arrOut)
-				execTime := time.Since(startTime)
-
-				// Ignore the first run because it is always artificially slower
-				if i > 0 {
-					fmt.Printf("\t\tExec time %d: %s\n", i, execTime)
-					fmt.Fprintf(fout, "%d,", int(execTime))
-
-					// Add all times to average them
-					execTimeAvg += int(execTime)
-				}
-
-				// Copy arrIn to arrOut for the next iteration
-				copy(arrOut, arrIn)
-
-				// Sleep between runs to let the CPUs cool down
-				time.Sleep(sleepTime * time.Second)
-			}
-
-			// Calculate average
-			execTimeAvg = execTimeAvg / runs
-			fmt.Printf("\t\tExec time avg: %dns\n", execTimeAvg)
-			fmt.Fprintf(fout, "%d\n", execTimeAvg)
-
-		case "quicksort":
-			// Run quicksort
-			fmt.Printf("\tQuicksort:\n")
-			fmt.Fprintf(fout, "quickSort,")
-
-			// Setup variables to calculate the execution time averages
-			execTimeAvg = 0
-
-			// Run benchmarks
-			for i := 0; i <= runs; i++ {
-				// Quicksort sorts in place, so pass arrOut to preserve arrIn
-				startTime := time.Now()
-				arrOut = quicksort.Sort(arrOut)
-				execTime := time.Since(startTime)
-
-				// Ignore the first run because it is always artificially slower
-				if i > 0 {
-					fmt.Printf("\t\tExec time %d: %s\n", i, execTime)
-					fmt.Fprintf(fout, "%d,", int(execTime))
-
-					// Add all times to average them
-					execTimeAvg += int(execTime)
-				}
-
-				// Copy arrIn to arrOut for the next iteration
-				copy(arrOut, arrIn)
-
-				// Sleep between runs to let the CPUs cool down
-				time.Sleep(sleepTime * time.Second)
-			}
-
-			// Calculate average
-			execTimeAvg = execTimeAvg / runs
-			fmt.Printf("\t\tExec time avg: %dns\n", execTimeAvg)
-			fmt.Fprintf(fout, "%d\n", execTimeAvg)
-
-		case "radixsort":
-			// Run radix sort
-			fmt.Printf("\tRadix Sort:\n")
-			fmt.Fprintf(fout, "radixsort,")
-
-			// Setup variables to calculate the execution time averages
-			execTimeAvg = 0
-
-			// Run benchmarks
-			for i := 0; i <= runs; i++ {
-				// Radix sort overwrites the input array, so pass arrOut to preserve arrIn
-				startTime := time.Now()
-				arrOut = radixsort.Sort(arrOut, k)
-				execTime := time.Since(startTime)
-
-				// Ignore the first run because it is always artificially slower
-				if i > 0 {
-					fmt.Printf("\t\tExec time %d: %s\n", i, execTime)
-					fmt.Fprintf(fout, "%d,", int(execTime))
-
-					// Add all times to average them
-					execTimeAvg += int(execTime)
-				}
+		s, ok := sorter.Lookup(alg)
+		if !ok {
+			fmt.Printf("ERROR: %s is not a valid algoritm\nSkipping...\n", alg)
+			continue
+		}
 
-				// Copy arrIn to arrOut for the next iteration
-				copy(arrOut, arrIn)
+		fmt.Printf("\t%s:\n", alg)
+		fmt.Fprintf(fout, "%s,", alg)
 
-				// Sleep between runs to let the CPUs cool down
-				time.Sleep(sleepTime * time.Second)
+		// Run benchmarks
+		execTimes, err := timeRuns(s, arrIn, arrOut)
+		if err != nil {
+			fmt.Printf("ERROR: %s could not sort the input: %v\nSkipping...\n", alg, err)
+			fmt.Fprintf(fout, "\n")
+			continue
+		}
+		execTimeAvg := average(execTimes)
+		printTimes(fout, execTimes)
+		fmt.Fprintf(fout, "%d,", int(execTimeAvg))
+
+		// Run the sequential baseline of the algorithm, if it has one, to
+		// calculate the speedup T1/Tp, and the efficiency T1/(p*Tp)
+		var seqTimeAvg time.Duration
+		if b, ok := sorter.LookupBaseline(alg); ok {
+			fmt.Printf("\t%s (sequential):\n", alg)
+			seqTimes, err := timeRuns(b, arrIn, arrOut)
+			if err != nil {
+				log.Fatalln("Could not time the sequential", alg, err)
 			}
+			seqTimeAvg = average(seqTimes)
+		}
 
-			// Calculate average
-			execTimeAvg = execTimeAvg / runs
-			fmt.Printf("\t\tExec time avg: %dns\n", execTimeAvg)
-			fmt.Fprintf(fout, "%d\n", execTimeAvg)
+		if seqTimeAvg > 0 && execTimeAvg > 0 {
+			speedup := float64(seqTimeAvg) / float64(execTimeAvg)
+			fmt.Printf("\t\tSpeedup: %.3f\n\t\tEfficiency: %.3f\n", speedup, speedup/float64(procs))
+			fmt.Fprintf(fout, "%d,%.3f,%.3f,", int(seqTimeAvg), speedup, speedup/float64(procs))
+		} else {
+			fmt.Fprintf(fout, ",,,")
+		}
 
-		default:
-			fmt.Printf("ERROR: %s is not a valid algoritm\nSkipping...\n", alg)
+		if execTimeAvg > 0 {
+			speedup := float64(sortIntsAvg) / float64(execTimeAvg)
+			fmt.Printf("\t\tSpeedup over sort.Ints: %.3f\n", speedup)
+			fmt.Fprintf(fout, "%.3f\n", speedup)
+		} else {
+			fmt.Fprintf(fout, "\n")
 		}
 	}
 
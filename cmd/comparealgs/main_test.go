@@ -2,25 +2,96 @@
 package main
 
 import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
 )
 
-// TestMaxNumDigits checks maxNumDigits with a multitude of input arrays.
-func TestMaxNumDigits(t *testing.T) {
+// TestReadInput checks readInput with a multitude of input files.
+func TestReadInput(t *testing.T) {
 	cases := []struct {
-		in   []int
-		want int
+		content string
+		format  int
+		want    []int
+		wantErr bool
 	}{
-		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, 1},
-		{[]int{7, 6, 5, 45, 3, 26, 1, 10}, 2},
-		{[]int{999, 4, 295, 666, 43, 66, 6, 576}, 3},
+		{"3,0,5,7,1,6,2,4", 0, []int{3, 0, 5, 7, 1, 6, 2, 4}, false},
+		{"3,0,five", 0, nil, true},
+		{"3\n0\n5\n7\n", 1, []int{3, 0, 5, 7}, false},
+		{"3\nfive\n", 1, nil, true},
+		{"3\n", 2, nil, true},
+	}
+
+	dir := t.TempDir()
+	for i, c := range cases {
+		inFile := filepath.Join(dir, "input.txt")
+		if err := ioutil.WriteFile(inFile, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		arrIn, arrOut, n, err := readInput(inFile, c.format)
+
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("case %d: readInput (%q, %d) returned no error", i, c.content, c.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: readInput (%q, %d) returned error %v", i, c.content, c.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(arrIn, c.want) || !reflect.DeepEqual(arrOut, c.want) || n != len(c.want) {
+			t.Errorf("case %d: readInput (%q, %d) == %v, %v, %d, want %v", i, c.content, c.format,
+				arrIn, arrOut, n, c.want)
+		}
 	}
 
-	for _, c := range cases {
-		got := maxNumDigits(c.in)
+	if _, _, _, err := readInput(filepath.Join(dir, "missing.txt"), 1); err == nil {
+		t.Errorf("readInput of a missing file returned no error")
+	}
+	if _, _, _, err := readInput("", 2); !errors.Is(err, errUnknownFormat) {
+		t.Errorf("readInput with format 2 returned error %v, want %v", err, errUnknownFormat)
+	}
+}
 
-		if got != c.want {
-			t.Errorf("maxNumDigits (%v) == %d, want %d", c.in, got, c.want)
+// TestTimeRuns checks timeRuns sorts the input, restores it after each run,
+// and returns the execution time of each run but the first.
+func TestTimeRuns(t *testing.T) {
+	runs, sleepTime = 3, 0
+	in := []int{3, 0, 5, 7, 1, 6, 2, 4}
+
+	var calls int
+	s := sorter.Sequential(func(arr []int) []int {
+		if !reflect.DeepEqual(arr, in) {
+			t.Errorf("run %d: got input %v, want %v", calls, arr, in)
 		}
+		calls++
+		sort.Ints(arr)
+		return arr
+	})
+
+	arrIn := append([]int(nil), in...)
+	arrOut := append([]int(nil), in...)
+	execTimes, err := timeRuns(s, arrIn, arrOut)
+	if err != nil {
+		t.Fatalf("timeRuns returned error %v", err)
+	}
+	if len(execTimes) != runs || calls != runs+1 {
+		t.Errorf("timeRuns returned %d times after %d runs, want %d times after %d runs",
+			len(execTimes), calls, runs, runs+1)
+	}
+
+	if got := average([]time.Duration{1, 2, 6}); got != 3 {
+		t.Errorf("average ([1 2 6]) == %d, want 3", got)
+	}
+	if got := average(nil); got != 0 {
+		t.Errorf("average ([]) == %d, want 0", got)
 	}
 }
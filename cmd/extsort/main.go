@@ -0,0 +1,70 @@
+// Package extsort provides a utility to sort a file of integers that may be
+// larger than the available memory, using the external parallel mergesort.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/carlosgvaso/parallel-sort/extsort"
+)
+
+// OutFile is the output file's path.
+var outFile string = "output.txt"
+
+// InFile is the input file's path.
+var inFile string = "input.txt"
+
+// InFileFormat is the input file's format.
+//
+// Formats are: 0 for CSV, 1 for array entry per line
+var inFileFormat int = 0
+
+// Error codes.
+var exitArg int = 1 // Exit bad arguments
+var exitErr int = 2 // Exit unknown error
+
+// Main sorts the integers in the input file, and writes them to the output file
+// in the same format.
+//
+// Interrupting it removes the temporary files and the partial output file.
+func main() {
+	// Check command-line arguments
+	inFilePtr := flag.String("input", inFile, "Input file's path")
+	inFileFormatPtr := flag.Int("format", inFileFormat, "Input file's format")
+	outFilePtr := flag.String("output", outFile, "Output file's path")
+	memPtr := flag.Int64("mem", extsort.DefaultMemoryBudget, "Memory budget in bytes")
+	tmpDirPtr := flag.String("tmpdir", "", "Directory for temporary files (default the system's)")
+	procsPtr := flag.Int("procs", 0, "Maximum number of goroutines sorting (default GOMAXPROCS)")
+	descPtr := flag.Bool("desc", false, "Sort in descending order")
+	flag.Parse()
+
+	inFile = *inFilePtr
+	inFileFormat = *inFileFormatPtr
+	outFile = *outFilePtr
+
+	if *memPtr <= 0 {
+		fmt.Printf("ERROR: mem must be > 0\n")
+		os.Exit(exitArg)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	opts := extsort.Options{
+		Format:       extsort.Format(inFileFormat),
+		MemoryBudget: *memPtr,
+		TempDir:      *tmpDirPtr,
+		MaxProcs:     *procsPtr,
+		Descending:   *descPtr,
+	}
+	if err := extsort.SortFile(ctx, inFile, outFile, opts); err != nil {
+		stop()
+		log.Println(err)
+		os.Exit(exitErr)
+	}
+}
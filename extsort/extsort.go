@@ -0,0 +1,292 @@
+// Package extsort provides an external parallel mergesort to sort files of
+// integers larger than the available memory.
+//
+// The input is read in chunks that fit in a memory budget. Each chunk is sorted
+// by the parallel mergesort, and spilled to a temporary file as a sorted run.
+// The runs are then merged with the parallel k-way merge of the mergesort
+// package, a block of each run at a time, and written to the output.
+//
+// Temporary files are removed when sorting returns, even if it fails or ctx
+// is done.
+package extsort
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/carlosgvaso/parallel-sort/mergesort"
+)
+
+// Format is the format of the input and output files. The values match the
+// formats of the comparealgs input files.
+type Format int
+
+const (
+	// CSV is a file of comma-separated integers. Newlines also separate
+	// entries, so the integers may span multiple lines.
+	CSV Format = iota
+
+	// EntryPerLine is a file with a single integer per line.
+	EntryPerLine
+)
+
+const (
+	// DefaultMemoryBudget is the memory budget in bytes when
+	// Options.MemoryBudget is 0.
+	DefaultMemoryBudget = 64 << 20
+
+	// entrySize is the size in bytes of an entry in memory and in the runs.
+	entrySize = 8
+
+	// maxFanIn is the maximum number of runs merged at once. If there are more
+	// runs, they are merged in groups into longer runs first, so the number of
+	// open files stays bounded.
+	maxFanIn = 128
+)
+
+// ErrInvalidOptions is returned when the options are not valid.
+var ErrInvalidOptions = errors.New("extsort: invalid options")
+
+// Options configures Sort and SortFile.
+type Options struct {
+	// Format is the format of the input, which is also used for the output.
+	Format Format
+
+	// MemoryBudget is the approximate number of bytes used to hold entries in
+	// memory. If it is 0, DefaultMemoryBudget is used.
+	MemoryBudget int64
+
+	// TempDir is the directory for the temporary files. If it is empty,
+	// os.TempDir() is used.
+	TempDir string
+
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0 or less, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
+
+	// Descending sorts the entries in descending order instead of ascending.
+	Descending bool
+}
+
+// SortFile sorts the integers in the file inFile, and writes them to the file
+// outFile in the same format.
+//
+// The output file is removed if sorting fails.
+func SortFile(ctx context.Context, inFile, outFile string, opts Options) error {
+	fin, err := os.Open(inFile)
+	if err != nil {
+		return fmt.Errorf("could not open the input file: %w", err)
+	}
+	defer fin.Close()
+
+	fout, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("could not create the output file: %w", err)
+	}
+
+	err = Sort(ctx, fin, fout, opts)
+	if cerr := fout.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("could not write the output file: %w", cerr)
+	}
+	if err != nil {
+		os.Remove(outFile)
+	}
+
+	return err
+}
+
+// Sort sorts the integers read from r, and writes them to w in the same
+// format.
+//
+// It stops sorting when ctx is done, and returns ctx.Err(). In that case, or
+// if it fails, part of the output may have been written to w.
+func Sort(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	if opts.MemoryBudget < 0 || (opts.Format != CSV && opts.Format != EntryPerLine) {
+		return fmt.Errorf("%w: %+v", ErrInvalidOptions, opts)
+	}
+	if opts.MemoryBudget == 0 {
+		opts.MemoryBudget = DefaultMemoryBudget
+	}
+
+	less := func(a, b int) bool { return a < b }
+	if opts.Descending {
+		less = func(a, b int) bool { return a > b }
+	}
+
+	// Mergesort needs a scratch buffer as long as the chunk, so a chunk takes
+	// half of the budget.
+	chunkLen := int(opts.MemoryBudget / (2 * entrySize))
+	if chunkLen < 1 {
+		chunkLen = 1
+	}
+	sopts := mergesort.Options{MaxProcs: opts.MaxProcs, Descending: opts.Descending}
+
+	in := newEntryReader(r, opts.Format)
+	out := newEntryWriter(w, opts.Format)
+
+	chunk := make([]int, 0, chunkLen)
+	var tmp *tempDir
+	defer func() {
+		if tmp != nil {
+			tmp.remove()
+		}
+	}()
+
+	for {
+		var eof bool
+		var err error
+		chunk, eof, err = in.read(chunk[:0])
+		if err != nil {
+			return err
+		}
+		if _, err := mergesort.SortWithOptions(ctx, chunk, sopts); err != nil {
+			return err
+		}
+
+		// Inputs that fit in a single chunk are written without spilling.
+		if eof && tmp == nil {
+			if err := out.write(chunk); err != nil {
+				return err
+			}
+			return out.flush()
+		}
+
+		if len(chunk) > 0 {
+			if tmp == nil {
+				if tmp, err = newTempDir(opts.TempDir); err != nil {
+					return err
+				}
+			}
+			if err := tmp.spill(chunk); err != nil {
+				return err
+			}
+		}
+		if eof {
+			break
+		}
+	}
+	// Release the chunk, since the merge uses its own blocks.
+	chunk = nil
+
+	mopts := mergesort.Options{MaxProcs: opts.MaxProcs}
+	if err := tmp.reduce(ctx, chunkLen, less, mopts); err != nil {
+		return err
+	}
+	if err := mergeRuns(ctx, tmp.runs, chunkLen, less, mopts, out.write); err != nil {
+		return err
+	}
+
+	return out.flush()
+}
+
+// EntryReader reads integers from a file in one of the formats.
+type entryReader struct {
+	sc    *bufio.Scanner
+	entry int // Number of entries read
+}
+
+// NewEntryReader returns a reader of the integers in r in the format f.
+func newEntryReader(r io.Reader, f Format) *entryReader {
+	sep := byte('\n')
+	if f == CSV {
+		sep = ','
+	}
+
+	seps := string([]byte{sep, '\n'})
+
+	sc := bufio.NewScanner(r)
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexAny(data, seps); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+
+	return &entryReader{sc: sc}
+}
+
+// Read appends integers to chunk until it is full or the input ends.
+//
+// It returns the chunk, and whether the input ended. Empty entries, like the
+// empty line at the end of a file, are skipped.
+func (r *entryReader) read(chunk []int) ([]int, bool, error) {
+	for len(chunk) < cap(chunk) {
+		if !r.sc.Scan() {
+			if err := r.sc.Err(); err != nil {
+				return nil, false, fmt.Errorf("could not read the input: %w", err)
+			}
+			return chunk, true, nil
+		}
+
+		tok := bytes.TrimSpace(r.sc.Bytes())
+		if len(tok) == 0 {
+			continue
+		}
+
+		num, err := strconv.Atoi(string(tok))
+		if err != nil {
+			return nil, false, fmt.Errorf("could not parse entry %d of the input: %w", r.entry, err)
+		}
+		chunk = append(chunk, num)
+		r.entry++
+	}
+
+	return chunk, false, nil
+}
+
+// EntryWriter writes integers to a file in one of the formats.
+type entryWriter struct {
+	w     *bufio.Writer
+	csv   bool
+	first bool
+	buf   []byte
+}
+
+// NewEntryWriter returns a writer of integers to w in the format f.
+func newEntryWriter(w io.Writer, f Format) *entryWriter {
+	return &entryWriter{w: bufio.NewWriter(w), csv: f == CSV, first: true}
+}
+
+// Write writes the entries to the output.
+func (w *entryWriter) write(entries []int) error {
+	for _, v := range entries {
+		w.buf = w.buf[:0]
+		if w.csv && !w.first {
+			w.buf = append(w.buf, ',')
+		}
+		w.buf = strconv.AppendInt(w.buf, int64(v), 10)
+		if !w.csv {
+			w.buf = append(w.buf, '\n')
+		}
+		w.first = false
+
+		if _, err := w.w.Write(w.buf); err != nil {
+			return fmt.Errorf("could not write the output: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Flush ends the output, and writes any buffered data.
+func (w *entryWriter) flush() error {
+	if w.csv && !w.first {
+		if err := w.w.WriteByte('\n'); err != nil {
+			return fmt.Errorf("could not write the output: %w", err)
+		}
+	}
+	if err := w.w.Flush(); err != nil {
+		return fmt.Errorf("could not write the output: %w", err)
+	}
+
+	return nil
+}
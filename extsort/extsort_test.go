@@ -0,0 +1,195 @@
+// Test external parallel mergesort
+package extsort
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// format returns the entries of arr in the format f, as written by Sort.
+func format(arr []int, f Format) string {
+	var b strings.Builder
+	for i, v := range arr {
+		if f == CSV && i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(v))
+		if f == EntryPerLine {
+			b.WriteByte('\n')
+		}
+	}
+	if f == CSV && len(arr) > 0 {
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// TestSort checks Sort with both formats and memory budgets that need no runs,
+// a few runs, and more runs than are merged at once.
+func TestSort(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 10, 1000} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(2000) - 1000
+		}
+		asc := make([]int, n)
+		copy(asc, in)
+		sort.Ints(asc)
+		desc := make([]int, n)
+		copy(desc, in)
+		sort.Sort(sort.Reverse(sort.IntSlice(desc)))
+
+		for _, f := range []Format{CSV, EntryPerLine} {
+			for _, budget := range []int64{0, 1, 16 * 100} {
+				for _, descending := range []bool{false, true} {
+					dir := t.TempDir()
+					opts := Options{Format: f, MemoryBudget: budget, TempDir: dir, Descending: descending}
+					want := format(asc, f)
+					if descending {
+						want = format(desc, f)
+					}
+
+					var out bytes.Buffer
+					err := Sort(context.Background(), strings.NewReader(format(in, f)), &out, opts)
+					if err != nil || out.String() != want {
+						t.Errorf("Sort (n = %d, %+v) returned error %v or a wrong output", n, opts, err)
+					}
+					if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+						t.Errorf("Sort (n = %d, %+v) left %d temporary files", n, opts, len(entries))
+					}
+				}
+			}
+		}
+	}
+
+	// Empty entries are skipped.
+	var out bytes.Buffer
+	if err := Sort(context.Background(), strings.NewReader("3\n\n1\n2"), &out, Options{Format: EntryPerLine}); err != nil ||
+		out.String() != "1\n2\n3\n" {
+		t.Errorf("Sort (%q) == %q, %v, want %q", "3\n\n1\n2", out.String(), err, "1\n2\n3\n")
+	}
+}
+
+// TestSortErrors checks Sort returns errors and removes the temporary files on
+// bad input, bad options and cancellation.
+func TestSortErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		ctx     context.Context
+		in      string
+		opts    Options
+		wantErr error
+	}{
+		{context.Background(), "3,1,five,2", Options{MemoryBudget: 16}, strconv.ErrSyntax},
+		{context.Background(), "3\n1\n2\n", Options{Format: 2}, ErrInvalidOptions},
+		{context.Background(), "3\n1\n2\n", Options{MemoryBudget: -1}, ErrInvalidOptions},
+		{canceled, "3,1,2,0", Options{MemoryBudget: 16}, context.Canceled},
+	}
+
+	for i, c := range cases {
+		dir := t.TempDir()
+		c.opts.TempDir = dir
+
+		err := Sort(c.ctx, strings.NewReader(c.in), &bytes.Buffer{}, c.opts)
+		if !errors.Is(err, c.wantErr) {
+			t.Errorf("case %d: Sort (%q, %+v) returned error %v, want %v", i, c.in, c.opts, err, c.wantErr)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+			t.Errorf("case %d: Sort (%q, %+v) left %d temporary files", i, c.in, c.opts, len(entries))
+		}
+	}
+}
+
+// countdownCtx is a context that is canceled after n calls of Err.
+type countdownCtx struct {
+	context.Context
+	n atomic.Int64
+}
+
+func (c *countdownCtx) Err() error {
+	if c.n.Add(-1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+// TestSortCancel checks Sort removes the temporary files when ctx is done after
+// some runs are spilled, while they are reduced, and while they are merged.
+func TestSortCancel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	in := r.Perm(3 * maxFanIn)
+	input := format(in, EntryPerLine)
+
+	// A budget of one entry per chunk spills one run per entry, so the runs
+	// are reduced before the last merge
+	opts := Options{Format: EntryPerLine, MemoryBudget: 2 * entrySize}
+
+	// Count the calls of Err of a whole sort
+	ctx := &countdownCtx{Context: context.Background()}
+	ctx.n.Store(math.MaxInt64)
+	opts.TempDir = t.TempDir()
+	if err := Sort(ctx, strings.NewReader(input), &bytes.Buffer{}, opts); err != nil {
+		t.Fatalf("Sort returned error %v", err)
+	}
+	calls := math.MaxInt64 - ctx.n.Load()
+
+	for i := int64(0); i <= 40; i++ {
+		stop := calls * i / 40
+		ctx := &countdownCtx{Context: context.Background()}
+		ctx.n.Store(stop)
+		opts.TempDir = t.TempDir()
+
+		err := Sort(ctx, strings.NewReader(input), &bytes.Buffer{}, opts)
+		if stop < calls && !errors.Is(err, context.Canceled) {
+			t.Errorf("Sort (canceled after %d of %d checks) returned error %v, want %v", stop, calls, err,
+				context.Canceled)
+		}
+		if entries, _ := os.ReadDir(opts.TempDir); len(entries) > 0 {
+			t.Errorf("Sort (canceled after %d of %d checks) left %d temporary files", stop, calls, len(entries))
+		}
+	}
+}
+
+// TestSortFile checks SortFile writes the output file, and removes it on
+// errors.
+func TestSortFile(t *testing.T) {
+	dir := t.TempDir()
+	inFile := filepath.Join(dir, "input.txt")
+	outFile := filepath.Join(dir, "output.txt")
+	opts := Options{MemoryBudget: 32, TempDir: dir}
+
+	if err := os.WriteFile(inFile, []byte("3,0,5,7,1,6,2,4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SortFile(context.Background(), inFile, outFile, opts); err != nil {
+		t.Errorf("SortFile returned error %v", err)
+	}
+	if got, _ := os.ReadFile(outFile); string(got) != "0,1,2,3,4,5,6,7\n" {
+		t.Errorf("SortFile wrote %q, want %q", got, "0,1,2,3,4,5,6,7\n")
+	}
+
+	if err := os.WriteFile(inFile, []byte("3,0,five"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SortFile(context.Background(), inFile, outFile, opts); err == nil {
+		t.Errorf("SortFile of a bad input returned no error")
+	}
+	if _, err := os.Stat(outFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SortFile of a bad input left the output file")
+	}
+}
@@ -0,0 +1,258 @@
+package extsort
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/carlosgvaso/parallel-sort/mergesort"
+)
+
+// TempDir is a temporary directory holding the sorted runs.
+type tempDir struct {
+	path string
+	runs []string // Paths of the runs, in the order they were spilled
+}
+
+// NewTempDir creates a temporary directory in dir.
+func newTempDir(dir string) (*tempDir, error) {
+	path, err := os.MkdirTemp(dir, "extsort-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create the temporary directory: %w", err)
+	}
+
+	return &tempDir{path: path}, nil
+}
+
+// Remove removes the temporary directory and all the runs in it.
+func (t *tempDir) remove() error {
+	return os.RemoveAll(t.path)
+}
+
+// Spill writes the sorted chunk to a new run.
+func (t *tempDir) spill(chunk []int) error {
+	w, err := t.create()
+	if err != nil {
+		return err
+	}
+	if err := w.write(chunk); err != nil {
+		w.close()
+		return err
+	}
+
+	return w.close()
+}
+
+// Create creates a new empty run, and adds it to the runs.
+func (t *tempDir) create() (*runWriter, error) {
+	f, err := os.CreateTemp(t.path, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create a run file: %w", err)
+	}
+	t.runs = append(t.runs, f.Name())
+
+	return &runWriter{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// Reduce merges the runs in groups of maxFanIn runs until there are at most
+// maxFanIn runs left.
+//
+// The merged runs are removed as soon as they are merged, so the temporary
+// files take about the size of the input at most.
+func (t *tempDir) reduce(ctx context.Context, blockLen int, less func(a, b int) bool,
+	opts mergesort.Options) error {
+	for len(t.runs) > maxFanIn {
+		runs := t.runs
+		t.runs = nil
+
+		for lo := 0; lo < len(runs); lo += maxFanIn {
+			hi := lo + maxFanIn
+			if hi > len(runs) {
+				hi = len(runs)
+			}
+			if hi-lo == 1 {
+				t.runs = append(t.runs, runs[lo])
+				continue
+			}
+
+			w, err := t.create()
+			if err != nil {
+				return err
+			}
+			err = mergeRuns(ctx, runs[lo:hi], blockLen, less, opts, w.write)
+			if cerr := w.close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, run := range runs[lo:hi] {
+				os.Remove(run)
+			}
+		}
+	}
+
+	return nil
+}
+
+// MergeRuns merges the sorted runs in the files paths, and passes the merged
+// entries to emit in order, a block at a time.
+//
+// The entries of the runs are read in blocks. Each round merges all the
+// entries that can not be preceded by entries of the runs not read yet: the
+// entries up to the last entry read of the run that sorts first. This run is
+// merged entirely, so each round makes progress. The memory used is about
+// twice blockLen.
+func mergeRuns(ctx context.Context, paths []string, blockLen int, less func(a, b int) bool,
+	opts mergesort.Options, emit func([]int) error) error {
+	k := len(paths)
+	readers := make([]*runReader, 0, k)
+	defer func() {
+		for _, r := range readers {
+			r.close()
+		}
+	}()
+	for _, path := range paths {
+		r, err := openRun(path)
+		if err != nil {
+			return err
+		}
+		readers = append(readers, r)
+	}
+
+	block := blockLen / k
+	if block < 1 {
+		block = 1
+	}
+	pending := make([][]int, k)
+	eof := make([]bool, k)
+	takes := make([][]int, k)
+	var merged []int
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Refill the blocks, and find the last entry read that sorts first.
+		var bound int
+		haveBound := false
+		for i, r := range readers {
+			if !eof[i] && len(pending[i]) < block {
+				var err error
+				if pending[i], eof[i], err = r.read(pending[i], block); err != nil {
+					return err
+				}
+			}
+			if !eof[i] {
+				if last := pending[i][len(pending[i])-1]; !haveBound || less(last, bound) {
+					bound, haveBound = last, true
+				}
+			}
+		}
+
+		n := 0
+		for i := range readers {
+			take := len(pending[i])
+			if haveBound {
+				take = sort.Search(take, func(j int) bool { return less(bound, pending[i][j]) })
+			}
+			takes[i] = pending[i][:take]
+			n += take
+		}
+		if n == 0 {
+			return nil
+		}
+
+		if cap(merged) < n {
+			merged = make([]int, n)
+		}
+		if _, err := mergesort.MergeRunsFuncWithOptions(ctx, merged[:n], takes, less, opts); err != nil {
+			return err
+		}
+		if err := emit(merged[:n]); err != nil {
+			return err
+		}
+
+		for i := range readers {
+			pending[i] = append(pending[i][:0], pending[i][len(takes[i]):]...)
+		}
+	}
+}
+
+// RunWriter writes entries to a run file.
+type runWriter struct {
+	f   *os.File
+	w   *bufio.Writer
+	buf [entrySize]byte
+}
+
+// Write appends the entries to the run.
+func (w *runWriter) write(entries []int) error {
+	for _, v := range entries {
+		binary.LittleEndian.PutUint64(w.buf[:], uint64(v))
+		if _, err := w.w.Write(w.buf[:]); err != nil {
+			return fmt.Errorf("could not write a run file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close flushes and closes the run file.
+func (w *runWriter) close() error {
+	err := w.w.Flush()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write a run file: %w", err)
+	}
+
+	return nil
+}
+
+// RunReader reads entries from a run file.
+type runReader struct {
+	f   *os.File
+	r   *bufio.Reader
+	buf [entrySize]byte
+}
+
+// OpenRun opens the run file path for reading.
+func openRun(path string) (*runReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open a run file: %w", err)
+	}
+
+	return &runReader{f: f, r: bufio.NewReader(f)}, nil
+}
+
+// Read appends entries to block until it has n entries or the run ends.
+//
+// It returns the block, and whether the run ended.
+func (r *runReader) read(block []int, n int) ([]int, bool, error) {
+	for len(block) < n {
+		if _, err := io.ReadFull(r.r, r.buf[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return block, true, nil
+			}
+			return nil, false, fmt.Errorf("could not read a run file: %w", err)
+		}
+		block = append(block, int(binary.LittleEndian.Uint64(r.buf[:])))
+	}
+
+	return block, false, nil
+}
+
+// Close closes the run file.
+func (r *runReader) close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,86 @@
+// Package stabletest provides the fixtures of the stability tests of the
+// sorting packages.
+//
+// The entries are pairs of a key, which they are sorted by, and a payload,
+// which is their original position. A sort is stable if the pairs end up
+// sorted by key, and the pairs with equal keys are in payload order.
+package stabletest
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Pair is an entry of a stability test.
+type Pair struct {
+	Key     int
+	Payload int
+}
+
+// Less reports whether a sorts before b by key.
+func Less(a, b Pair) bool {
+	return a.Key < b.Key
+}
+
+// Pairs returns n pairs with random keys in [0, keys), and their position as
+// payload.
+func Pairs(r *rand.Rand, n int, keys int) []Pair {
+	in := make([]Pair, n)
+	for i := range in {
+		in[i] = Pair{r.Intn(keys), i}
+	}
+	return in
+}
+
+// Runs returns n pairs made of runs of random lengths up to maxRun, with their
+// position as payload. Each run is ascending, descending or random, with keys
+// around [0, keys), and the ascending and descending runs have equal keys.
+func Runs(r *rand.Rand, n int, keys int, maxRun int) []Pair {
+	in := make([]Pair, n)
+	for i := 0; i < n; {
+		run := 1 + r.Intn(maxRun)
+		if i+run > n {
+			run = n - i
+		}
+		key := r.Intn(keys)
+		kind := r.Intn(3)
+		for j := i; j < i+run; j++ {
+			switch kind {
+			case 0:
+				key += r.Intn(2)
+			case 1:
+				key -= r.Intn(2)
+			default:
+				key = r.Intn(keys)
+			}
+			in[j] = Pair{key, j}
+		}
+		i += run
+	}
+	return in
+}
+
+// Check returns an error if got is not a stable sort of pairs with payloads 0
+// to len(got)-1: if it is not sorted by key, in descending order if desc, if
+// pairs with equal keys are not in payload order, or if a payload is missing.
+func Check(got []Pair, desc bool) error {
+	seen := make([]bool, len(got))
+	for i, p := range got {
+		if p.Payload < 0 || p.Payload >= len(got) || seen[p.Payload] {
+			return fmt.Errorf("payload %d at index %d is out of range or repeated", p.Payload, i)
+		}
+		seen[p.Payload] = true
+
+		if i == 0 {
+			continue
+		}
+		prev := got[i-1]
+		if (!desc && Less(p, prev)) || (desc && Less(prev, p)) {
+			return fmt.Errorf("%v before %v at index %d is out of order", prev, p, i)
+		}
+		if prev.Key == p.Key && prev.Payload > p.Payload {
+			return fmt.Errorf("%v before %v at index %d is not stable", prev, p, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Test stability test fixtures
+package stabletest
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestCheck checks Check accepts stable sorts, and rejects the others.
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		got  []Pair
+		desc bool
+		ok   bool
+	}{
+		{[]Pair{}, false, true},
+		{[]Pair{{1, 1}, {1, 2}, {2, 0}}, false, true},
+		{[]Pair{{2, 0}, {1, 1}, {1, 2}}, true, true},
+		{[]Pair{{2, 0}, {1, 1}, {1, 2}}, false, false}, // Out of order
+		{[]Pair{{1, 2}, {1, 1}, {2, 0}}, false, false}, // Not stable
+		{[]Pair{{1, 1}, {1, 1}, {2, 0}}, false, false}, // Repeated payload
+		{[]Pair{{1, 1}, {1, 2}, {2, 3}}, false, false}, // Missing payload
+	}
+
+	for _, c := range cases {
+		if err := Check(c.got, c.desc); (err == nil) != c.ok {
+			t.Errorf("Check (%v, desc = %v) == %v, want ok = %v", c.got, c.desc, err, c.ok)
+		}
+	}
+}
+
+// TestGenerators checks Pairs and Runs return pairs that Check accepts once
+// stably sorted.
+func TestGenerators(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, in := range [][]Pair{Pairs(r, 1000, 16), Runs(r, 1000, 100, 50)} {
+		sort.SliceStable(in, func(i, j int) bool { return Less(in[i], in[j]) })
+		if err := Check(in, false); err != nil {
+			t.Errorf("Check (stably sorted) == %v", err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package mergesort
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/carlosgvaso/parallel-sort/permute"
+)
+
+// Argsort returns the permutation of indices that sorts an array of any ordered
+// type using the parallel mergesort algorithm.
+//
+// The entry i of the result is the index in arr of the entry at position i of
+// the sorted array. Equal entries keep their relative order, and arr is left
+// unchanged. Use permute.Apply to sort arr, or other arrays, by the result.
+func Argsort[T cmp.Ordered](arr []T) []int {
+	return ArgsortFunc(arr, cmp.Less[T])
+}
+
+// ArgsortFunc is like Argsort, but for arrays of any type sorted by less.
+func ArgsortFunc[T any](arr []T, less func(a, b T) bool) []int {
+	perm, _ := ArgsortFuncWithOptions(context.Background(), arr, less, Options{})
+	return perm
+}
+
+// ArgsortFuncWithOptions is like ArgsortFunc, but it stops sorting when ctx is
+// done, and it is configured by opts.
+func ArgsortFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool, opts Options) ([]int, error) {
+	perm := permute.Identity(len(arr))
+
+	// Sort the indices by the entries they point to. Mergesort is stable, so
+	// the indices of equal entries stay in ascending order.
+	return SortFuncWithOptions(ctx, perm, func(i, j int) bool {
+		return less(arr[i], arr[j])
+	}, opts)
+}
@@ -0,0 +1,39 @@
+// Test parallel mergesort argsort
+package mergesort
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgsort(t *testing.T) {
+	cases := []struct {
+		in   []int
+		want []int
+	}{
+		{[]int{}, []int{}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4}, []int{1, 4, 6, 0, 7, 2, 5, 3}},
+		// Equal entries keep their original order.
+		{[]int{2, 1, 2, 1, 0}, []int{4, 1, 3, 0, 2}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got := Argsort(arrIn)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Argsort (%v) == %v, want %v", c.in, got, c.want)
+		}
+		if !reflect.DeepEqual(arrIn, c.in) {
+			t.Errorf("Argsort (%v) modified the input to %v", c.in, arrIn)
+		}
+	}
+
+	names := []string{"carol", "alice", "bob"}
+	got := ArgsortFunc(names, func(a, b string) bool { return len(a) < len(b) })
+	if want := []int{2, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsortFunc (%v, by length) == %v, want %v", names, got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package mergesort
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// SortBottomUp sorts an array in place using the parallel bottom-up mergesort
+// algorithm.
+//
+// Bottom-up mergesort splits the array in one block per goroutine, sorts the
+// blocks concurrently, and then merges them in passes that double the length
+// of the sorted runs. Each pass splits its output evenly between the
+// goroutines, so the work of each goroutine is fixed by the length of the
+// array, unlike the recursive Sort. Like mergesort, it is stable.
+// It returns the input array sorted.
+func SortBottomUp(arr []int) []int {
+	return SortBottomUpFunc(arr, cmp.Less[int])
+}
+
+// SortBottomUpContext is like SortBottomUp, but it stops sorting when ctx is
+// done.
+func SortBottomUpContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortBottomUpFuncWithOptions(ctx, arr, cmp.Less[int], Options{})
+}
+
+// SortBottomUpSequential sorts an array in place using the bottom-up mergesort
+// algorithm on a single goroutine.
+//
+// It is the baseline to measure the speedup of SortBottomUp.
+func SortBottomUpSequential(arr []int) []int {
+	arr, _ = SortBottomUpFuncWithOptions(context.Background(), arr, cmp.Less[int], Options{MaxProcs: 1})
+	return arr
+}
+
+// SortBottomUpFunc is like SortBottomUp, but for arrays of any type sorted by
+// less.
+func SortBottomUpFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortBottomUpFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortBottomUpFuncWithOptions is like SortBottomUpFunc, but it stops sorting
+// when ctx is done, and it is configured by opts.
+//
+// Blocks have at least opts.Cutoff entries, and the passes are split in parts
+// of at least opts.Cutoff entries, so short arrays use fewer goroutines than
+// opts.MaxProcs. Cancellation is checked before each pass.
+func SortBottomUpFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	th := opts.thresholds()
+	pool := workers.New(opts.MaxProcs)
+	n := len(arr)
+	if n < 2 {
+		return arr, ctx.Err()
+	}
+
+	// One block per goroutine, unless the blocks would be shorter than the
+	// cutoff
+	width := (n + pool.Size() - 1) / pool.Size()
+	if width < th.parallel {
+		width = th.parallel
+	}
+	blocks := (n + width - 1) / width
+
+	// Each pass merges from one array into the other. Sort the blocks into
+	// buf if the number of passes is odd, so the last pass ends in arr.
+	passes := 0
+	for w := width; w < n; w *= 2 {
+		passes++
+	}
+	buf := make([]T, n)
+	toBuf := passes%2 == 1
+
+	if err := ctx.Err(); err != nil {
+		return arr, err
+	}
+	pool.For(blocks, 1, func(lo, hi int) {
+		for b := lo; b < hi; b++ {
+			start, end := b*width, (b+1)*width
+			if end > n {
+				end = n
+			}
+			mergesort(arr[start:end], buf[start:end], toBuf, less, th)
+		}
+	})
+
+	src, dst := arr, buf
+	if toBuf {
+		src, dst = buf, arr
+	}
+	for ; width < n; width *= 2 {
+		if err := ctx.Err(); err != nil {
+			// Merges only write whole sorted runs, so both arrays hold
+			// all the entries. Copy the most sorted ones back to arr if
+			// the last pass ended in buf.
+			if &src[0] != &arr[0] {
+				copy(arr, src)
+			}
+			return arr, err
+		}
+
+		mergePass(pool, dst, src, width, less, th)
+		src, dst = dst, src
+	}
+
+	return arr, nil
+}
+
+// MergePass merges each pair of consecutive runs of width entries of src into
+// dst.
+//
+// The output is split in equal parts of at least th.parallel entries, one per
+// goroutine in the pool at most. Each part may take the end of a merge, whole
+// merges, and the start of another merge; the entries of the runs that go to a
+// part are found by coRank.
+func mergePass[T any](pool *workers.Pool, dst, src []T, width int, less func(a, b T) bool, th thresholds) {
+	n := len(src)
+	parts := workers.Chunks(n, th.parallel, pool.Size())
+	size := (n + parts - 1) / parts
+
+	pool.For(parts, 1, func(plo, phi int) {
+		// The last parts are empty if the parts do not divide n evenly
+		lo, hi := min(plo*size, n), min(phi*size, n)
+		if lo == hi {
+			return
+		}
+
+		for start := lo - lo%(2*width); start < hi; start += 2 * width {
+			middle, end := start+width, start+2*width
+			if middle > n {
+				middle = n
+			}
+			if end > n {
+				end = n
+			}
+			a, b := src[start:middle], src[middle:end]
+
+			// Output range of this merge that belongs to the part, relative
+			// to the start of the merge
+			from, to := 0, end-start
+			if lo > start {
+				from = lo - start
+			}
+			if hi < end {
+				to = hi - start
+			}
+
+			i, j := coRank(from, a, b, less)
+			k, l := coRank(to, a, b, less)
+			merge(dst[start+from:start+to], a[i:k], b[j:l], less)
+		}
+	})
+}
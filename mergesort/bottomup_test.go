@@ -0,0 +1,119 @@
+// Test parallel bottom-up mergesort
+package mergesort
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
+)
+
+func TestSortBottomUp(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{}, []int{}},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{7, 6, 5, 4, 3, 2, 1, 0}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{1, 3, 5, 7, 6, 4, 2, 0}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got := SortBottomUp(arrIn)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortBottomUp (%v) == %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+// TestSortBottomUpStable checks SortBottomUpFuncWithOptions keeps equal entries
+// in their original order with different numbers of blocks and passes.
+func TestSortBottomUpStable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{{}, {Cutoff: -1, MaxProcs: 4}, {Cutoff: 7, MaxProcs: 3}, {Cutoff: 10, MaxProcs: 16},
+		{Descending: true, Cutoff: 64, MaxProcs: 5}} {
+		for _, n := range []int{1, 2, 3, 13, 500, 1 << 14} {
+			in := stabletest.Pairs(r, n, n/4+1)
+
+			got, err := SortBottomUpFuncWithOptions(context.Background(), in, stabletest.Less, opts)
+			if err == nil {
+				err = stabletest.Check(got, opts.Descending)
+			}
+			if err != nil {
+				t.Errorf("SortBottomUpFuncWithOptions (n = %d, %+v): %v", n, opts, err)
+			}
+		}
+	}
+
+	// Short arrays split in parts of a few entries, so some parts of a pass
+	// may start past the end of the array
+	for _, cutoff := range []int{-1, 1, 3} {
+		for procs := 1; procs <= 8; procs++ {
+			for n := 2; n <= 40; n++ {
+				in := stabletest.Pairs(r, n, n/4+1)
+
+				opts := Options{Cutoff: cutoff, MaxProcs: procs}
+				got, err := SortBottomUpFuncWithOptions(context.Background(), in, stabletest.Less, opts)
+				if err == nil {
+					err = stabletest.Check(got, false)
+				}
+				if err != nil {
+					t.Errorf("SortBottomUpFuncWithOptions (n = %d, %+v): %v", n, opts, err)
+				}
+			}
+		}
+	}
+}
+
+// TestSortBottomUpContext checks SortBottomUpFuncWithOptions leaves a
+// permutation of the input when ctx is done in any pass.
+func TestSortBottomUpContext(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	in := r.Perm(1000)
+
+	// With 8 blocks of 125 entries there are 3 passes, and the blocks are
+	// sorted into the scratch buffer.
+	for _, stop := range []int64{1, 5000, 8000, 9000, 10000, 12000, 20000} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int64
+		less := func(a, b int) bool {
+			if atomic.AddInt64(&calls, 1) == stop {
+				cancel()
+			}
+			return a < b
+		}
+
+		arrIn := make([]int, len(in))
+		copy(arrIn, in)
+		got, err := SortBottomUpFuncWithOptions(ctx, arrIn, less, Options{MaxProcs: 8, Cutoff: 1})
+		cancel()
+
+		// The last pass is not canceled, so the array may end up sorted.
+		if err == nil && !sort.IntsAreSorted(got) {
+			t.Errorf("SortBottomUpFuncWithOptions (canceled after %d calls) returned no error, but did not sort",
+				stop)
+		} else if err != nil && err != context.Canceled {
+			t.Errorf("SortBottomUpFuncWithOptions (canceled after %d calls) returned error %v, want %v",
+				stop, err, context.Canceled)
+		}
+
+		sort.Ints(got)
+		for i, v := range got {
+			if v != i {
+				t.Errorf("SortBottomUpFuncWithOptions (canceled after %d calls) did not leave a permutation of the input",
+					stop)
+				break
+			}
+		}
+	}
+}
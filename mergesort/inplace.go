@@ -0,0 +1,190 @@
+package mergesort
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// SortInPlace sorts an array in place using the parallel in-place mergesort
+// algorithm.
+//
+// In-place mergesort merges by rotations, following the SymMerge algorithm, so
+// it needs no scratch buffer: besides the goroutines, it only uses O(log n)
+// stack space. It does O(n log² n) work instead of O(n log n), so it is slower
+// than Sort, and it is meant for arrays too large for a second copy. Like
+// mergesort, it is stable.
+// It returns the input array sorted.
+func SortInPlace(arr []int) []int {
+	return SortInPlaceFunc(arr, cmp.Less[int])
+}
+
+// SortInPlaceContext is like SortInPlace, but it stops sorting when ctx is
+// done.
+func SortInPlaceContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortInPlaceFuncWithOptions(ctx, arr, cmp.Less[int], Options{})
+}
+
+// SortInPlaceSequential sorts an array in place using the in-place mergesort
+// algorithm on a single goroutine.
+//
+// It is the baseline to measure the speedup of SortInPlace.
+func SortInPlaceSequential(arr []int) []int {
+	arr, _ = SortInPlaceFuncWithOptions(context.Background(), arr, cmp.Less[int],
+		Options{MaxProcs: 1, Cutoff: math.MaxInt})
+	return arr
+}
+
+// SortInPlaceFunc is like SortInPlace, but for arrays of any type sorted by
+// less.
+func SortInPlaceFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortInPlaceFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortInPlaceFuncWithOptions is like SortInPlaceFunc, but it stops sorting when
+// ctx is done, and it is configured by opts.
+//
+// Both the halves of subarrays, and the two merges each merge is split in, are
+// handed to goroutines down to opts.Cutoff entries.
+func SortInPlaceFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	inPlaceMergesort(ctx, workers.New(opts.MaxProcs), arr, less, opts.thresholds())
+	return arr, ctx.Err()
+}
+
+// InPlaceMergesort sorts s in place by sorting its halves, in parallel if s
+// has at least th.parallel entries, and merging them with symMerge.
+//
+// It returns without merging if ctx is done. Rotations keep s a permutation of
+// its original entries at all times.
+func inPlaceMergesort[T any](ctx context.Context, pool *workers.Pool, s []T, less func(a, b T) bool,
+	th thresholds) {
+	if len(s) <= th.insertion {
+		insertionSort(s, less)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	middle := len(s) / 2
+	if len(s) < th.parallel {
+		inPlaceMergesort(ctx, pool, s[:middle], less, th)
+		inPlaceMergesort(ctx, pool, s[middle:], less, th)
+	} else {
+		var wg sync.WaitGroup
+		pool.Go(&wg, func() {
+			inPlaceMergesort(ctx, pool, s[:middle], less, th)
+		})
+		inPlaceMergesort(ctx, pool, s[middle:], less, th)
+		wg.Wait()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	symMerge(pool, s, middle, less, th)
+}
+
+// SymMerge merges the sorted subarrays s[:m] and s[m:] in place.
+//
+// It is the SymMerge algorithm by Pok-Son Kim and Arne Kutzner, "Stable
+// Minimum Storage Merging by Symmetric Comparisons", as used by the sort
+// package of the standard library. A binary search finds the largest rotation
+// of the entries around the middle of s that leaves every entry of the two
+// sides of the middle in order with the other side. After that rotation, both
+// sides are merged recursively, and independently.
+func symMerge[T any](pool *workers.Pool, s []T, m int, less func(a, b T) bool, th thresholds) {
+	n := len(s)
+	if m == 0 || m == n {
+		return
+	}
+
+	// A single entry is inserted in the other side by binary search and
+	// rotation. Equal entries of the left side stay first.
+	if m == 1 {
+		i := 1 + sort.Search(n-1, func(i int) bool { return !less(s[1+i], s[0]) })
+		rotate(pool, s[:i], 1, th)
+		return
+	}
+	if n-m == 1 {
+		i := sort.Search(m, func(i int) bool { return less(s[m], s[i]) })
+		rotate(pool, s[i:], m-i, th)
+		return
+	}
+
+	mid := n / 2
+	k := mid + m
+	var start, r int
+	if m > mid {
+		start, r = k-n, mid
+	} else {
+		start, r = 0, m
+	}
+	p := k - 1
+	for start < r {
+		c := int(uint(start+r) >> 1)
+		if !less(s[p-c], s[c]) {
+			start = c + 1
+		} else {
+			r = c
+		}
+	}
+
+	end := k - start
+	if start < m && m < end {
+		rotate(pool, s[start:end], m-start, th)
+	}
+
+	if n < th.parallel {
+		symMerge(pool, s[:mid], start, less, th)
+		symMerge(pool, s[mid:], end-mid, less, th)
+		return
+	}
+
+	var wg sync.WaitGroup
+	pool.Go(&wg, func() {
+		symMerge(pool, s[:mid], start, less, th)
+	})
+	symMerge(pool, s[mid:], end-mid, less, th)
+	wg.Wait()
+}
+
+// Rotate rotates s left by m entries in place, so s[m:] is moved in front of
+// s[:m], by reversing both parts and then the whole of s.
+//
+// The reversals are split between goroutines from the pool if s has at least
+// th.parallel entries.
+func rotate[T any](pool *workers.Pool, s []T, m int, th thresholds) {
+	if len(s) < th.parallel {
+		reverse(s[:m])
+		reverse(s[m:])
+		reverse(s)
+		return
+	}
+
+	parallelReverse(pool, s[:m], th.parallel)
+	parallelReverse(pool, s[m:], th.parallel)
+	parallelReverse(pool, s, th.parallel)
+}
+
+// ParallelReverse is like reverse, but the swaps are split in chunks of at
+// least grain swaps that are run by goroutines from the pool.
+func parallelReverse[T any](pool *workers.Pool, s []T, grain int) {
+	n := len(s)
+	pool.For(n/2, grain, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			s[i], s[n-1-i] = s[n-1-i], s[i]
+		}
+	})
+}
@@ -0,0 +1,55 @@
+// Test parallel in-place mergesort
+package mergesort
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
+)
+
+func TestSortInPlace(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{}, []int{}},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{7, 6, 5, 4, 3, 2, 1, 0}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{1, 3, 5, 7, 6, 4, 2, 0}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got := SortInPlace(arrIn)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortInPlace (%v) == %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+// TestSortInPlaceStable checks SortInPlaceFuncWithOptions keeps equal entries
+// in their original order with different cutoffs.
+func TestSortInPlaceStable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{{}, {Cutoff: -1, MaxProcs: 4}, {Cutoff: 50, InsertionCutoff: -1, MaxProcs: 3},
+		{Descending: true, Cutoff: 64}} {
+		for _, n := range []int{1, 2, 3, 13, 500, 1 << 14} {
+			in := stabletest.Pairs(r, n, n/4+1)
+
+			got, err := SortInPlaceFuncWithOptions(context.Background(), in, stabletest.Less, opts)
+			if err == nil {
+				err = stabletest.Check(got, opts.Descending)
+			}
+			if err != nil {
+				t.Errorf("SortInPlaceFuncWithOptions (n = %d, %+v): %v", n, opts, err)
+			}
+		}
+	}
+}
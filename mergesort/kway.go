@@ -0,0 +1,265 @@
+package mergesort
+
+import (
+	"cmp"
+	"context"
+	"sort"
+
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// oversample is the number of splitter candidates taken from each run for each
+// output partition.
+const oversample = 8
+
+// MergeRuns merges arrays that are already sorted into a new sorted array
+// using a parallel k-way merge.
+//
+// Equal entries keep their relative order, and entries of earlier runs are
+// placed first. The runs are not modified.
+func MergeRuns(runs [][]int) []int {
+	return MergeRunsOrdered(runs)
+}
+
+// MergeRunsInto is like MergeRuns, but it writes the merged array into dst,
+// which must be as long as all the runs together. It returns dst.
+func MergeRunsInto(dst []int, runs [][]int) []int {
+	dst, _ = MergeRunsFuncWithOptions(context.Background(), dst, runs, cmp.Less[int], Options{})
+	return dst
+}
+
+// MergeRunsOrdered is like MergeRuns, but for arrays of any ordered type.
+func MergeRunsOrdered[T cmp.Ordered](runs [][]T) []T {
+	return MergeRunsFunc(runs, cmp.Less[T])
+}
+
+// MergeRunsFunc is like MergeRuns, but for arrays of any type sorted by less.
+func MergeRunsFunc[T any](runs [][]T, less func(a, b T) bool) []T {
+	dst, _ := MergeRunsFuncWithOptions(context.Background(), nil, runs, less, Options{})
+	return dst
+}
+
+// MergeRunsFuncWithOptions is like MergeRunsFunc, but it stops merging when ctx
+// is done, and it is configured by opts.
+//
+// The runs must be sorted in the order given by less and opts.Descending. The
+// merged array is written into dst, which must be as long as all the runs
+// together, or into a new array if dst is nil. It returns the merged array.
+//
+// The output is split in partitions of at least opts.Cutoff entries, one per
+// goroutine at most, that are merged by a loser tree each. Cancellation is
+// checked before each partition, so it returns ctx.Err() and a partially
+// merged array if ctx is done.
+func MergeRunsFuncWithOptions[T any](ctx context.Context, dst []T, runs [][]T, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	n := 0
+	for _, run := range runs {
+		n += len(run)
+	}
+	if dst == nil {
+		dst = make([]T, n)
+	}
+	if len(dst) != n {
+		panic("mergesort: MergeRuns destination length does not match the runs")
+	}
+
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	pool := workers.New(opts.MaxProcs)
+	parts := workers.Chunks(n, opts.thresholds().parallel, pool.Size())
+	bounds := splitRuns(runs, parts, less)
+
+	pool.For(parts, 1, func(lo, hi int) {
+		for q := lo; q < hi && ctx.Err() == nil; q++ {
+			segs := make([][]T, len(runs))
+			for k, run := range runs {
+				segs[k] = run[bounds[q][k]:bounds[q+1][k]]
+			}
+
+			start := 0
+			for _, b := range bounds[q] {
+				start += b
+			}
+			mergeSegments(dst[start:], segs, less)
+		}
+	})
+
+	return dst, ctx.Err()
+}
+
+// SplitRuns splits the runs in parts output partitions of balanced lengths.
+//
+// It returns parts+1 boundaries. Partition q takes runs[k][b[q][k]:b[q+1][k]]
+// from each run k. Each inner boundary is the lower bound in every run of a
+// splitter picked from evenly spaced samples of the runs, so all the entries
+// equal to a splitter go to the same partition and the merge stays stable.
+func splitRuns[T any](runs [][]T, parts int, less func(a, b T) bool) [][]int {
+	n := 0
+	for _, run := range runs {
+		n += len(run)
+	}
+
+	bounds := make([][]int, parts+1)
+	bounds[0] = make([]int, len(runs))
+	bounds[parts] = make([]int, len(runs))
+	for k, run := range runs {
+		bounds[parts][k] = len(run)
+	}
+	if parts == 1 {
+		return bounds
+	}
+
+	var samples []T
+	for _, run := range runs {
+		step := len(run) / (parts * oversample)
+		if step < 1 {
+			step = 1
+		}
+		for i := step - 1; i < len(run); i += step {
+			samples = append(samples, run[i])
+		}
+	}
+	mergesort(samples, make([]T, len(samples)), false, less, thresholds{insertion: DefaultInsertionCutoff})
+
+	// LowerBounds returns the number of entries before v in each run, and
+	// their sum, which is the rank of v in the merged array.
+	lowerBounds := func(v T) ([]int, int) {
+		pos := make([]int, len(runs))
+		rank := 0
+		for k, run := range runs {
+			pos[k] = sort.Search(len(run), func(i int) bool { return !less(run[i], v) })
+			rank += pos[k]
+		}
+		return pos, rank
+	}
+
+	for q := 1; q < parts; q++ {
+		target := q * n / parts
+		s := sort.Search(len(samples), func(i int) bool {
+			_, rank := lowerBounds(samples[i])
+			return rank >= target
+		})
+
+		if s == len(samples) {
+			bounds[q] = bounds[parts]
+		} else {
+			bounds[q], _ = lowerBounds(samples[s])
+		}
+
+		// Boundaries can not go back, even if the splitters are equal.
+		for k := range runs {
+			if bounds[q][k] < bounds[q-1][k] {
+				bounds[q][k] = bounds[q-1][k]
+			}
+		}
+	}
+
+	return bounds
+}
+
+// MergeSegments merges the sorted segments into dst, placing entries of earlier
+// segments first when they are equal.
+func mergeSegments[T any](dst []T, segs [][]T, less func(a, b T) bool) {
+	// Empty segments are dropped, keeping the order of the others.
+	live := segs[:0:0]
+	for _, seg := range segs {
+		if len(seg) > 0 {
+			live = append(live, seg)
+		}
+	}
+
+	switch len(live) {
+	case 0:
+		return
+	case 1:
+		copy(dst, live[0])
+		return
+	case 2:
+		merge(dst[:len(live[0])+len(live[1])], live[0], live[1], less)
+		return
+	}
+
+	t := newLoserTree(live, less)
+	for i := 0; ; i++ {
+		v, ok := t.pop()
+		if !ok {
+			return
+		}
+		dst[i] = v
+	}
+}
+
+// LoserTree is a tournament tree over the heads of k sorted segments. Each
+// inner node keeps the segment that lost the match played there, so replacing
+// the winner only replays the matches on its path to the root.
+type loserTree[T any] struct {
+	segs [][]T
+	tree []int // tree[0] is the winner, tree[1:] are the losers
+	less func(a, b T) bool
+}
+
+// NewLoserTree returns a loser tree over the non-empty segments segs.
+func newLoserTree[T any](segs [][]T, less func(a, b T) bool) *loserTree[T] {
+	k := len(segs)
+	t := &loserTree[T]{segs: segs, tree: make([]int, k), less: less}
+
+	// The leaves are the nodes k to 2k-1, and the winner of the subtree of each
+	// node is kept in winners while the tree is built bottom up.
+	winners := make([]int, 2*k)
+	for i := 0; i < k; i++ {
+		winners[k+i] = i
+	}
+	for node := k - 1; node >= 1; node-- {
+		l, r := winners[2*node], winners[2*node+1]
+		if t.beats(l, r) {
+			winners[node], t.tree[node] = l, r
+		} else {
+			winners[node], t.tree[node] = r, l
+		}
+	}
+	t.tree[0] = winners[1]
+
+	return t
+}
+
+// Beats reports whether the head of segment i sorts before the head of segment
+// j. Exhausted segments always lose, and ties go to the earlier segment.
+func (t *loserTree[T]) beats(i, j int) bool {
+	switch {
+	case len(t.segs[i]) == 0:
+		return false
+	case len(t.segs[j]) == 0:
+		return true
+	case t.less(t.segs[j][0], t.segs[i][0]):
+		return false
+	case t.less(t.segs[i][0], t.segs[j][0]):
+		return true
+	}
+	return i < j
+}
+
+// Pop removes and returns the smallest head of all segments. It returns false
+// when all segments are exhausted.
+func (t *loserTree[T]) pop() (T, bool) {
+	w := t.tree[0]
+	if len(t.segs[w]) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	v := t.segs[w][0]
+	t.segs[w] = t.segs[w][1:]
+
+	k := len(t.segs)
+	for node := (w + k) / 2; node >= 1; node /= 2 {
+		if t.beats(t.tree[node], w) {
+			t.tree[node], w = w, t.tree[node]
+		}
+	}
+	t.tree[0] = w
+
+	return v, true
+}
@@ -0,0 +1,88 @@
+// Test parallel k-way merge
+package mergesort
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
+)
+
+func TestMergeRuns(t *testing.T) {
+	cases := []struct {
+		in   [][]int
+		want []int
+	}{
+		{nil, []int{}},
+		{[][]int{{}, {}}, []int{}},
+		{[][]int{{1, 2, 3}}, []int{1, 2, 3}},
+		{[][]int{{1, 4}, {}, {0, 2, 3}}, []int{0, 1, 2, 3, 4}},
+		{[][]int{{5, 6}, {0, 7}, {1, 1}, {2}, {3, 4}}, []int{0, 1, 1, 2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, c := range cases {
+		got := MergeRuns(c.in)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("MergeRuns (%v) == %v, want %v", c.in, got, c.want)
+		}
+	}
+
+	dst := make([]int, 4)
+	if got, want := MergeRunsInto(dst, [][]int{{2, 3}, {1, 4}}), []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) ||
+		&got[0] != &dst[0] {
+		t.Errorf("MergeRunsInto ([[2 3] [1 4]]) == %v, want %v in dst", got, want)
+	}
+}
+
+// TestMergeRunsStable checks MergeRunsFuncWithOptions keeps equal entries in
+// run order with many runs and partitions.
+func TestMergeRunsStable(t *testing.T) {
+	less := stabletest.Less
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{{}, {Cutoff: -1, MaxProcs: 4}, {Cutoff: 7, MaxProcs: 3}, {Descending: true, Cutoff: 50}} {
+		for _, k := range []int{1, 2, 3, 17, 100} {
+			// The payload is the position of the pair in the runs laid end to end.
+			var runs [][]stabletest.Pair
+			total := 0
+			for i := 0; i < k; i++ {
+				run := stabletest.Pairs(r, r.Intn(300), 40)
+				sort.SliceStable(run, func(i, j int) bool {
+					if opts.Descending {
+						return less(run[j], run[i])
+					}
+					return less(run[i], run[j])
+				})
+				for j := range run {
+					run[j].Payload = total + j
+				}
+				runs = append(runs, run)
+				total += len(run)
+			}
+
+			got, err := MergeRunsFuncWithOptions(context.Background(), nil, runs, less, opts)
+			if err == nil {
+				err = stabletest.Check(got, opts.Descending)
+			}
+			if err != nil {
+				t.Errorf("MergeRunsFuncWithOptions (%d runs, %+v): %v", k, opts, err)
+			}
+		}
+	}
+}
+
+// TestMergeRunsIntoPanics checks MergeRunsInto rejects a destination of the
+// wrong length.
+func TestMergeRunsIntoPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MergeRunsInto with a short destination did not panic")
+		}
+	}()
+
+	MergeRunsInto(make([]int, 2), [][]int{{1, 2}, {3}})
+}
@@ -1,48 +1,198 @@
+// Package mergesort provides a parallel mergesort implementation to sort
+// arrays of any ordered type.
+//
+// Mergesort is stable: equal entries keep their relative order. SortStable
+// makes this guarantee explicit for callers that depend on it.
+//
+// Subarrays are split between goroutines down to Options.Cutoff entries, then
+// sorted by sequential mergesort down to Options.InsertionCutoff entries, and
+// then by insertion sort. Merges of subarrays longer than Options.Cutoff are
+// split between goroutines as well. A single scratch buffer as long as the
+// input is allocated for each sort.
 package mergesort
 
 import (
-	
+	"cmp"
+	"context"
+	"sort"
 	"sync"
-	
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+const (
+	// DefaultCutoff is the length under which subarrays are sorted
+	// sequentially when Options.Cutoff is 0.
+	DefaultCutoff = 1 << 11
+
+	// DefaultInsertionCutoff is the length under which subarrays are sorted by
+	// insertion sort when Options.InsertionCutoff is 0.
+	DefaultInsertionCutoff = 12
 )
 
-const max = 1 << 11
+// Options configures SortWithOptions.
+type Options struct {
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0 or less, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
 
-func merge(s []int, middle int) {
-	helper := make([]int, len(s))
-	copy(helper, s)
+	// Descending sorts the array in descending order instead of ascending.
+	Descending bool
 
-	helperLeft := 0
-	helperRight := middle
-	current := 0
-	high := len(s) - 1
+	// Cutoff is the length under which subarrays are sorted sequentially
+	// instead of splitting them between goroutines. If it is 0,
+	// DefaultCutoff is used. If it is negative, subarrays are always split.
+	Cutoff int
+
+	// InsertionCutoff is the length under which subarrays are sorted by
+	// insertion sort instead of being split further. If it is 0,
+	// DefaultInsertionCutoff is used. If it is negative, subarrays are split
+	// down to single entries.
+	InsertionCutoff int
+}
+
+// Thresholds are the subarray lengths at which the recursion changes strategy.
+type thresholds struct {
+	parallel  int
+	insertion int
+}
 
-	for helperLeft <= middle-1 && helperRight <= high {
-		if helper[helperLeft] <= helper[helperRight] {
-			s[current] = helper[helperLeft]
-			helperLeft++
+// Thresholds returns the thresholds configured by opts.
+func (opts Options) thresholds() thresholds {
+	th := thresholds{parallel: opts.Cutoff, insertion: opts.InsertionCutoff}
+
+	if th.parallel == 0 {
+		th.parallel = DefaultCutoff
+	}
+	if th.insertion == 0 {
+		th.insertion = DefaultInsertionCutoff
+	}
+	if th.insertion < 1 {
+		th.insertion = 1
+	}
+	return th
+}
+
+func init() {
+	sorter.Register("mergesort", sorter.Func(SortContext))
+	sorter.Register("naturalmergesort", sorter.Func(SortNaturalContext))
+	sorter.Register("inplacemergesort", sorter.Func(SortInPlaceContext))
+	sorter.Register("bottomupmergesort", sorter.Func(SortBottomUpContext))
+	sorter.RegisterBaseline("mergesort", sorter.Sequential(SortSequential))
+	sorter.RegisterBaseline("naturalmergesort", sorter.Sequential(SortNaturalSequential))
+	sorter.RegisterBaseline("inplacemergesort", sorter.Sequential(SortInPlaceSequential))
+	sorter.RegisterBaseline("bottomupmergesort", sorter.Sequential(SortBottomUpSequential))
+}
+
+// Merge merges the sorted arrays a and b into dst, which must have room for
+// both of them.
+//
+// less reports whether a sorts before b. Entries of a are placed first when
+// they are equal, so the merge is stable.
+func merge[T any](dst, a, b []T, less func(a, b T) bool) {
+	// Arrays that are already in order are only copied.
+	if len(a) > 0 && len(b) > 0 && !less(b[0], a[len(a)-1]) {
+		copy(dst[copy(dst, a):], b)
+		return
+	}
+
+	i, j, k := 0, 0, 0
+
+	for i < len(a) && j < len(b) {
+		if !less(b[j], a[i]) {
+			dst[k] = a[i]
+			i++
 		} else {
-			s[current] = helper[helperRight]
-			helperRight++
+			dst[k] = b[j]
+			j++
 		}
-		current++
+		k++
+	}
+
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
+
+// ParallelMerge is like merge, but the output is split in contiguous
+// partitions of at least grain entries that are merged by goroutines from the
+// pool.
+//
+// The entries of a and b that go to each partition are found by coRank, so
+// partitions are balanced no matter how the entries of a and b interleave.
+func parallelMerge[T any](pool *workers.Pool, dst, a, b []T, grain int, less func(a, b T) bool) {
+	pool.For(len(dst), grain, func(lo, hi int) {
+		i, j := coRank(lo, a, b, less)
+		k, l := coRank(hi, a, b, less)
+		merge(dst[lo:hi], a[i:k], b[j:l], less)
+	})
+}
+
+// CoRank returns the number of entries i of a and j of b in the first n
+// entries of the stable merge of a and b, so that i+j == n.
+//
+// It binary searches the merge path: the first i such that b[n-i-1] sorts
+// before a[i]. Entries of a are taken first when they are equal.
+func coRank[T any](n int, a, b []T, less func(a, b T) bool) (i, j int) {
+	lo := n - len(b)
+	if lo < 0 {
+		lo = 0
+	}
+	hi := n
+	if hi > len(a) {
+		hi = len(a)
 	}
 
-	for helperLeft <= middle-1 {
-		s[current] = helper[helperLeft]
-		current++
-		helperLeft++
+	i = lo + sort.Search(hi-lo, func(x int) bool {
+		return less(b[n-lo-x-1], a[lo+x])
+	})
+	return i, n - i
+}
+
+// InsertionSort sorts s in place by insertion sort. It is stable, and faster
+// than mergesort for short arrays.
+func insertionSort[T any](s []T, less func(a, b T) bool) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && less(s[j], s[j-1]); j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
 	}
 }
 
 /* Sequential */
 
-func mergesort(s []int) {
-	if len(s) > 1 {
-		middle := len(s) / 2
-		mergesort(s[:middle])
-		mergesort(s[middle:])
-		merge(s, middle)
+// SortSequential sorts an array in place using the sequential mergesort
+// algorithm, with the same cutoffs as Sort and a single goroutine.
+//
+// It is the baseline to measure the speedup of Sort.
+// It returns the input array sorted.
+func SortSequential(arr []int) []int {
+	mergesort(arr, make([]int, len(arr)), false, cmp.Less[int], Options{}.thresholds())
+	return arr
+}
+
+// Mergesort sorts s, using buf as scratch space. buf must be as long as s.
+//
+// The sorted entries are left in buf if toBuf is true, or in s otherwise. The
+// halves are sorted into the other array, so each level merges from one array
+// into the other and no level needs to copy or allocate.
+func mergesort[T any](s, buf []T, toBuf bool, less func(a, b T) bool, th thresholds) {
+	if len(s) <= th.insertion {
+		insertionSort(s, less)
+		if toBuf {
+			copy(buf, s)
+		}
+		return
+	}
+
+	middle := len(s) / 2
+	mergesort(s[:middle], buf[:middle], !toBuf, less, th)
+	mergesort(s[middle:], buf[middle:], !toBuf, less, th)
+
+	if toBuf {
+		merge(buf, s[:middle], s[middle:], less)
+	} else {
+		merge(s, buf[:middle], buf[middle:], less)
 	}
 }
 
@@ -73,32 +223,121 @@ func mergesort(s []int) {
 // 	return nums, nil
 // }
 
+// ParallelMergesort is like mergesort, but the halves of subarrays of at least
+// th.parallel entries are sorted by goroutines from the pool, and then merged by
+// parallelMerge.
+//
+// It returns without merging if ctx is done. Merges only copy complete sorted
+// subarrays from buf to s, so s is always a permutation of its original
+// entries.
+func parallelMergesort[T any](ctx context.Context, pool *workers.Pool, s, buf []T, toBuf bool,
+	less func(a, b T) bool, th thresholds) {
+	if len(s) < th.parallel || len(s) <= th.insertion {
+		mergesort(s, buf, toBuf, less, th)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
 
-func parallelMergesort(s []int) {
-	len := len(s)
+	middle := len(s) / 2
 
-	if len > 1 {
-		middle := len / 2
+	var wg sync.WaitGroup
+	pool.Go(&wg, func() {
+		parallelMergesort(ctx, pool, s[:middle], buf[:middle], !toBuf, less, th)
+	})
+	parallelMergesort(ctx, pool, s[middle:], buf[middle:], !toBuf, less, th)
 
-		var wg sync.WaitGroup
-		wg.Add(2)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
 
-		go func() {
-			defer wg.Done()
-			parallelMergesort(s[:middle])
-		}()
+	// The merges of the upper levels are split between goroutines too, so
+	// the last levels do not run on a single goroutine.
+	if toBuf {
+		parallelMerge(pool, buf, s[:middle], s[middle:], th.parallel, less)
+	} else {
+		parallelMerge(pool, s, buf[:middle], buf[middle:], th.parallel, less)
+	}
+}
 
-		go func() {
-			defer wg.Done()
-			parallelMergesort(s[middle:])
-		}()
+// Sort sorts an array in place using the parallel mergesort algorithm.
+//
+// It takes an array as an input.
+// It returns the input array sorted.
+func Sort(arr []int) []int {
+	return SortOrdered(arr)
+}
 
-		wg.Wait()
-		merge(s, middle)
-	}
+// SortContext is like Sort, but it stops sorting when ctx is done.
+//
+// Cancellation is checked before each parallel recursive call and merge step,
+// and no more goroutines are started after ctx is done. In that case, it returns
+// ctx.Err() and the array is left partially sorted.
+func SortContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortWithOptions(ctx, arr, Options{})
 }
 
-func Sort(arr []int ) []int {
-	parallelMergesort(arr)	
+// SortWithOptions is like SortContext, but it is configured by opts.
+func SortWithOptions(ctx context.Context, arr []int, opts Options) ([]int, error) {
+	return SortOrderedWithOptions(ctx, arr, opts)
+}
+
+// SortOrdered sorts an array of any ordered type in place using the parallel
+// mergesort algorithm.
+//
+// It returns the input array sorted.
+func SortOrdered[T cmp.Ordered](arr []T) []T {
+	arr, _ = SortOrderedWithOptions(context.Background(), arr, Options{})
 	return arr
 }
+
+// SortOrderedWithOptions is like SortWithOptions, but for arrays of any
+// ordered type.
+func SortOrderedWithOptions[T cmp.Ordered](ctx context.Context, arr []T, opts Options) ([]T, error) {
+	return SortFuncWithOptions(ctx, arr, cmp.Less[T], opts)
+}
+
+// SortFunc sorts an array of any type in place using the parallel mergesort
+// algorithm.
+//
+// less reports whether a sorts before b. It must be a strict weak ordering, and
+// it is called from multiple goroutines at the same time.
+// It returns the input array sorted.
+func SortFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortStable sorts an array of any type in place using the parallel mergesort
+// algorithm, keeping equal entries in their original order.
+//
+// It is the same as SortFunc, since mergesort is always stable.
+func SortStable[T any](arr []T, less func(a, b T) bool) []T {
+	return SortFunc(arr, less)
+}
+
+// SortStableWithOptions is like SortStable, but it stops sorting when ctx is
+// done, and it is configured by opts.
+func SortStableWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	return SortFuncWithOptions(ctx, arr, less, opts)
+}
+
+// SortFuncWithOptions is like SortWithOptions, but for arrays of any type
+// sorted by less.
+func SortFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool, opts Options) ([]T, error) {
+	// Sort in descending order by swapping the arguments of less. Equal
+	// entries are still merged left first, so the sort stays stable.
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	// The scratch buffer is shared by all levels and goroutines, which only
+	// use the part of it matching their subarray.
+	buf := make([]T, len(arr))
+	parallelMergesort(ctx, workers.New(opts.MaxProcs), arr, buf, false, less, opts.thresholds())
+	return arr, ctx.Err()
+}
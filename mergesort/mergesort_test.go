@@ -2,8 +2,14 @@
 package mergesort
 
 import (
+	"context"
+	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
+	"github.com/carlosgvaso/parallel-sort/workers"
 )
 
 func TestSort(t *testing.T) {
@@ -28,3 +34,188 @@ func TestSort(t *testing.T) {
 		}
 	}
 }
+
+// TestSortContext checks SortContext stops sorting when the context is done.
+func TestSortContext(t *testing.T) {
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	arrIn := make([]int, len(in))
+	copy(arrIn, in)
+	if _, err := SortContext(ctx, arrIn); err != context.Canceled {
+		t.Errorf("SortContext (canceled, %v) returned error %v, want %v", in, err, context.Canceled)
+	}
+
+	copy(arrIn, in)
+	got, err := SortContext(context.Background(), arrIn)
+	if err != nil {
+		t.Errorf("SortContext (%v) returned error %v", in, err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortContext (%v) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortOrdered checks SortOrdered with arrays of other ordered types.
+func TestSortOrdered(t *testing.T) {
+	floats := SortOrdered([]float64{2.5, -1, 0, 3.25, -7.5, 2.5})
+	if want := []float64{-7.5, -1, 0, 2.5, 2.5, 3.25}; !reflect.DeepEqual(floats, want) {
+		t.Errorf("SortOrdered ([]float64) == %v, want %v", floats, want)
+	}
+
+	strs := SortOrdered([]string{"pear", "apple", "fig", "", "banana"})
+	if want := []string{"", "apple", "banana", "fig", "pear"}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("SortOrdered ([]string) == %v, want %v", strs, want)
+	}
+
+	uints := SortOrdered([]uint64{1 << 63, 7, 0, 1<<64 - 1, 42})
+	if want := []uint64{0, 7, 42, 1 << 63, 1<<64 - 1}; !reflect.DeepEqual(uints, want) {
+		t.Errorf("SortOrdered ([]uint64) == %v, want %v", uints, want)
+	}
+}
+
+// TestSortFunc checks SortFunc with an array of records.
+func TestSortFunc(t *testing.T) {
+	type record struct {
+		name string
+		age  int
+	}
+	in := []record{{"carol", 35}, {"alice", 30}, {"dave", 20}, {"bob", 25}, {"erin", 40}}
+	want := []record{{"dave", 20}, {"bob", 25}, {"alice", 30}, {"carol", 35}, {"erin", 40}}
+
+	got := SortFunc(in, func(a, b record) bool { return a.age < b.age })
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortFunc (%v, by age) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortStable checks SortStable keeps the payloads of entries with equal keys in
+// their original order.
+func TestSortStable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 10, 1000, 1 << 14} {
+		in := stabletest.Pairs(r, n, 16)
+
+		got, err := SortStableWithOptions(context.Background(), in, stabletest.Less, Options{MaxProcs: 4})
+		if err == nil {
+			err = stabletest.Check(got, false)
+		}
+		if err != nil {
+			t.Errorf("SortStable (n = %d): %v", n, err)
+		}
+	}
+}
+
+// TestSortThresholds checks SortWithOptions with different cutoffs, including
+// lengths around them.
+func TestSortThresholds(t *testing.T) {
+	cases := []Options{
+		{},
+		{Cutoff: -1},
+		{Cutoff: 1, InsertionCutoff: -1},
+		{Cutoff: 64, InsertionCutoff: 1},
+		{Cutoff: 100, InsertionCutoff: 33, MaxProcs: 3},
+		{Cutoff: 1 << 20, InsertionCutoff: 1 << 20},
+	}
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range cases {
+		for _, n := range []int{0, 1, 2, 12, 13, 33, 100, 101, 1000, 1 << 13} {
+			in := make([]int, n)
+			for i := range in {
+				in[i] = r.Intn(n + 1)
+			}
+			want := make([]int, n)
+			copy(want, in)
+			sort.Ints(want)
+
+			got, err := SortWithOptions(context.Background(), in, opts)
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("SortWithOptions (n = %d, %+v) returned error %v or an unsorted array",
+					n, opts, err)
+			}
+		}
+	}
+}
+
+// TestParallelMerge checks parallelMerge matches merge, including the order of
+// equal entries, for any grain.
+func TestParallelMerge(t *testing.T) {
+	type pair struct {
+		key     int
+		payload int
+	}
+	less := func(a, b pair) bool { return a.key < b.key }
+	pool := workers.New(4)
+	r := rand.New(rand.NewSource(1))
+
+	for _, sizes := range [][2]int{{0, 0}, {0, 5}, {5, 0}, {1, 1}, {7, 300}, {500, 500}, {1000, 3}} {
+		a := make([]pair, sizes[0])
+		b := make([]pair, sizes[1])
+		for i := range a {
+			a[i] = pair{r.Intn(20), i}
+		}
+		for i := range b {
+			b[i] = pair{r.Intn(20), len(a) + i}
+		}
+		insertionSort(a, less)
+		insertionSort(b, less)
+
+		want := make([]pair, len(a)+len(b))
+		merge(want, a, b, less)
+
+		for _, grain := range []int{-1, 1, 3, 64, 1 << 20} {
+			got := make([]pair, len(a)+len(b))
+			parallelMerge(pool, got, a, b, grain, less)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parallelMerge (%d and %d entries, grain %d) does not match merge",
+					len(a), len(b), grain)
+			}
+		}
+	}
+}
+
+// TestSortDescending checks SortWithOptions sorts in descending order.
+func TestSortDescending(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{7, 6, 5, 4, 3, 2, 1, 0}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4, 5}, []int{7, 6, 5, 5, 4, 3, 2, 1, 0}},
+		{[]int{42, 7, 999, 0, 130}, []int{999, 130, 42, 7, 0}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := SortWithOptions(context.Background(), arrIn, Options{Descending: true})
+
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortWithOptions (%v, descending) == %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+// TestSortSequential checks the sequential baseline sorts like Sort.
+func TestSortSequential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 7, 100, 1000} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(n + 1)
+		}
+		want := make([]int, n)
+		copy(want, in)
+		sort.Ints(want)
+
+		if got := SortSequential(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SortSequential (n = %d) == %v, want %v", n, got, want)
+		}
+	}
+}
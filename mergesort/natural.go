@@ -0,0 +1,201 @@
+package mergesort
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// SortNatural sorts an array in place using the parallel natural mergesort
+// algorithm.
+//
+// Natural mergesort merges the ascending and strictly descending runs already
+// in the array instead of splitting it in halves, so sorted and nearly sorted
+// arrays take close to linear time. Like mergesort, it is stable.
+// It returns the input array sorted.
+func SortNatural(arr []int) []int {
+	return SortNaturalFunc(arr, cmp.Less[int])
+}
+
+// SortNaturalContext is like SortNatural, but it stops sorting when ctx is
+// done.
+func SortNaturalContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortNaturalFuncWithOptions(ctx, arr, cmp.Less[int], Options{})
+}
+
+// SortNaturalSequential sorts an array in place using the natural mergesort
+// algorithm on a single goroutine.
+//
+// It is the baseline to measure the speedup of SortNatural.
+func SortNaturalSequential(arr []int) []int {
+	arr, _ = SortNaturalFuncWithOptions(context.Background(), arr, cmp.Less[int],
+		Options{MaxProcs: 1, Cutoff: math.MaxInt})
+	return arr
+}
+
+// SortNaturalFunc is like SortNatural, but for arrays of any type sorted by
+// less.
+func SortNaturalFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortNaturalFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortNaturalFuncWithOptions is like SortNaturalFunc, but it stops sorting when
+// ctx is done, and it is configured by opts.
+//
+// The runs are found by goroutines scanning chunks of at least opts.Cutoff
+// entries each. Runs shorter than opts.InsertionCutoff are extended to that
+// length by insertion sort, so random arrays are not split in tiny runs.
+func SortNaturalFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	th := opts.thresholds()
+	pool := workers.New(opts.MaxProcs)
+
+	bounds := findRuns(pool, arr, less, th)
+	if ctx.Err() != nil {
+		return arr, ctx.Err()
+	}
+
+	// A single run is already sorted, so there is nothing to merge.
+	if len(bounds) > 2 {
+		buf := make([]T, len(arr))
+		mergeNatural(ctx, pool, arr, buf, false, bounds, less, th)
+	}
+	return arr, ctx.Err()
+}
+
+// FindRuns finds the runs of s, reversing the strictly descending ones so all
+// of them are ascending. Runs are not strictly descending if they have equal
+// entries, so reversing them keeps the sort stable.
+//
+// It returns the start of each run followed by len(s). Chunks of s are scanned
+// in parallel, so runs are also split at chunk boundaries.
+func findRuns[T any](pool *workers.Pool, s []T, less func(a, b T) bool, th thresholds) []int {
+	type chunkRuns struct {
+		lo     int
+		starts []int
+	}
+
+	var mu sync.Mutex
+	var chunks []chunkRuns
+	pool.For(len(s), th.parallel, func(lo, hi int) {
+		starts := scanRuns(s, lo, hi, less, th.insertion)
+
+		mu.Lock()
+		chunks = append(chunks, chunkRuns{lo, starts})
+		mu.Unlock()
+	})
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].lo < chunks[j].lo })
+
+	bounds := []int{}
+	for _, c := range chunks {
+		bounds = append(bounds, c.starts...)
+	}
+	return append(bounds, len(s))
+}
+
+// ScanRuns returns the start of each run of s[lo:hi], after making the runs
+// ascending and extending the runs shorter than minRun.
+func scanRuns[T any](s []T, lo, hi int, less func(a, b T) bool, minRun int) []int {
+	var starts []int
+
+	for start := lo; start < hi; {
+		end := start + 1
+		if end < hi && less(s[end], s[end-1]) {
+			for end < hi && less(s[end], s[end-1]) {
+				end++
+			}
+			reverse(s[start:end])
+		} else {
+			for end < hi && !less(s[end], s[end-1]) {
+				end++
+			}
+		}
+
+		if end-start < minRun {
+			end = start + minRun
+			if end > hi {
+				end = hi
+			}
+			insertionSort(s[start:end], less)
+		}
+
+		starts = append(starts, start)
+		start = end
+	}
+
+	return starts
+}
+
+// Reverse reverses s in place.
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// MergeNatural merges the sorted runs of s, which start at bounds[:len(bounds)-1]
+// relative to bounds[0], using buf as scratch space like parallelMergesort.
+//
+// The runs are split in two groups at the run boundary closest to the middle of
+// s, so the merges are balanced even if the runs are not. Groups of at least
+// th.parallel entries are merged by goroutines from the pool.
+func mergeNatural[T any](ctx context.Context, pool *workers.Pool, s, buf []T, toBuf bool, bounds []int,
+	less func(a, b T) bool, th thresholds) {
+	if len(bounds) == 2 {
+		if toBuf {
+			copy(buf, s)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Find the inner boundary closest to the middle of s.
+	base := bounds[0]
+	half := base + len(s)/2
+	m := sort.SearchInts(bounds[1:len(bounds)-1], half) + 1
+	if m > 1 && (m == len(bounds)-1 || half-bounds[m-1] < bounds[m]-half) {
+		m--
+	}
+	middle := bounds[m] - base
+
+	left := func() {
+		mergeNatural(ctx, pool, s[:middle], buf[:middle], !toBuf, bounds[:m+1], less, th)
+	}
+	right := func() {
+		mergeNatural(ctx, pool, s[middle:], buf[middle:], !toBuf, bounds[m:], less, th)
+	}
+	if len(s) < th.parallel {
+		left()
+		right()
+	} else {
+		var wg sync.WaitGroup
+		pool.Go(&wg, left)
+		right()
+		wg.Wait()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	dst, src := s, buf
+	if toBuf {
+		dst, src = buf, s
+	}
+	if len(s) < th.parallel {
+		merge(dst, src[:middle], src[middle:], less)
+	} else {
+		parallelMerge(pool, dst, src[:middle], src[middle:], th.parallel, less)
+	}
+}
@@ -0,0 +1,55 @@
+// Test parallel natural mergesort
+package mergesort
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
+)
+
+func TestSortNatural(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{}, []int{}},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{7, 6, 5, 4, 3, 2, 1, 0}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{1, 3, 5, 7, 6, 4, 2, 0}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got := SortNatural(arrIn)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortNatural (%v) == %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+// TestSortNaturalRuns checks SortNaturalFuncWithOptions is stable with inputs
+// made of ascending, descending and random runs, and different cutoffs.
+func TestSortNaturalRuns(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{{}, {Cutoff: -1, MaxProcs: 4}, {Cutoff: 100, InsertionCutoff: -1, MaxProcs: 3},
+		{Descending: true, Cutoff: 64}} {
+		for _, n := range []int{1, 2, 13, 500, 1 << 14} {
+			in := stabletest.Runs(r, n, 100, 200)
+
+			got, err := SortNaturalFuncWithOptions(context.Background(), in, stabletest.Less, opts)
+			if err == nil {
+				err = stabletest.Check(got, opts.Descending)
+			}
+			if err != nil {
+				t.Errorf("SortNaturalFuncWithOptions (n = %d, %+v): %v", n, opts, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package permute provides parallel helpers to build and apply the index
+// permutations returned by the Argsort functions of the sorting packages.
+//
+// A permutation perm of length n holds each index in [0, n) exactly once, and
+// perm[i] is the index in the original array of the entry at position i of the
+// sorted array. Applying it to other columns of the same table sorts them in
+// the same order:
+//
+//	perm := mergesort.Argsort(ages)
+//	names = permute.Apply(names, perm)
+package permute
+
+import (
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// Grain is the minimum number of entries each goroutine copies.
+const grain int = 1 << 12
+
+// Identity returns the identity permutation of length n, [0, 1, ..., n-1].
+//
+// It is filled in parallel using runtime.GOMAXPROCS(0) goroutines at most.
+func Identity(n int) []int {
+	perm := make([]int, n)
+
+	workers.New(0).For(n, grain, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			perm[i] = i
+		}
+	})
+
+	return perm
+}
+
+// Apply returns a new array with the entries of col in the order given by perm,
+// that is, the entry i of the result is col[perm[i]]. col is left unchanged.
+//
+// It is filled in parallel using runtime.GOMAXPROCS(0) goroutines at most.
+// It panics if col and perm have different lengths.
+func Apply[T any](col []T, perm []int) []T {
+	dst := make([]T, len(col))
+	ApplyTo(dst, col, perm)
+
+	return dst
+}
+
+// ApplyTo is like Apply, but it writes the result to dst instead of a new array.
+//
+// dst and col must not overlap. It panics if dst, col and perm do not all have
+// the same length.
+func ApplyTo[T any](dst []T, col []T, perm []int) {
+	if len(dst) != len(perm) || len(col) != len(perm) {
+		panic("permute: dst, col and perm have different lengths")
+	}
+
+	workers.New(0).For(len(perm), grain, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			dst[i] = col[perm[i]]
+		}
+	})
+}
@@ -0,0 +1,55 @@
+// Test permutation helpers
+package permute
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestIdentity checks Identity with a multitude of lengths.
+func TestIdentity(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 10000} {
+		got := Identity(n)
+
+		if len(got) != n {
+			t.Errorf("Identity (%d) has length %d", n, len(got))
+		}
+		for i, v := range got {
+			if v != i {
+				t.Errorf("Identity (%d)[%d] == %d, want %d", n, i, v, i)
+				break
+			}
+		}
+	}
+}
+
+// TestApply checks Apply with a multitude of columns.
+func TestApply(t *testing.T) {
+	perm := []int{2, 0, 3, 1}
+
+	names := []string{"carol", "alice", "dave", "bob"}
+	gotNames := Apply(names, perm)
+	if want := []string{"dave", "carol", "bob", "alice"}; !reflect.DeepEqual(gotNames, want) {
+		t.Errorf("Apply (%v, %v) == %v, want %v", names, perm, gotNames, want)
+	}
+	if want := []string{"carol", "alice", "dave", "bob"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Apply (%v, %v) changed its input", names, perm)
+	}
+
+	ages := []float64{35.5, 30, 20, 25}
+	gotAges := Apply(ages, perm)
+	if want := []float64{20, 35.5, 25, 30}; !reflect.DeepEqual(gotAges, want) {
+		t.Errorf("Apply (%v, %v) == %v, want %v", ages, perm, gotAges, want)
+	}
+}
+
+// TestApplyToPanics checks ApplyTo panics when the lengths do not match.
+func TestApplyToPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("ApplyTo with different lengths did not panic")
+		}
+	}()
+
+	ApplyTo(make([]int, 2), []int{1, 2, 3}, []int{0, 1, 2})
+}
@@ -0,0 +1,241 @@
+// Package psort provides a single entry point to all the parallel sorting
+// algorithms in this module.
+//
+// The algorithm, and its parameters, are selected with Options:
+//
+//	arr, err := psort.Sort(arr, psort.Options{Algorithm: psort.Quicksort})
+package psort
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/carlosgvaso/parallel-sort/bitonicsort"
+	"github.com/carlosgvaso/parallel-sort/bricksort"
+	"github.com/carlosgvaso/parallel-sort/mergesort"
+	"github.com/carlosgvaso/parallel-sort/quicksort"
+	"github.com/carlosgvaso/parallel-sort/radixsort"
+	"github.com/carlosgvaso/parallel-sort/timsort"
+)
+
+// Algorithm names.
+const (
+	Bitonicsort string = "bitonicsort"
+	Bricksort   string = "bricksort"
+	Mergesort   string = "mergesort"
+	Quicksort   string = "quicksort"
+	Radixsort   string = "radixsort"
+
+	// NaturalMergesort merges the runs already in the input, so it is the
+	// fastest choice for sorted and nearly sorted inputs.
+	NaturalMergesort string = "naturalmergesort"
+
+	// InPlaceMergesort merges by rotations instead of using a scratch buffer,
+	// so it is the choice for inputs too large for a second copy.
+	InPlaceMergesort string = "inplacemergesort"
+
+	// BottomUpMergesort merges fixed blocks in passes split evenly between
+	// the goroutines, so its load does not depend on the scheduler.
+	BottomUpMergesort string = "bottomupmergesort"
+
+	// Timsort merges the runs already in the input with galloping merges, so
+	// it is stable and adapts to partially ordered inputs.
+	Timsort string = "timsort"
+)
+
+// DefaultAlgorithm is the algorithm used when Options.Algorithm is empty.
+const DefaultAlgorithm string = Mergesort
+
+// Errors returned by Sort.
+var (
+	ErrUnknownAlgorithm = errors.New("psort: unknown algorithm")
+	ErrInvalidOptions   = errors.New("psort: invalid options")
+	ErrUnsupportedInput = errors.New("psort: unsupported input")
+)
+
+// Options configures Sort.
+type Options struct {
+	// Algorithm is the name of the sorting algorithm to use. If it is empty,
+	// DefaultAlgorithm is used.
+	Algorithm string
+
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
+
+	// Cutoff is the input length below which the sequential sort.Ints is used
+	// instead of the parallel algorithm. If it is 0, the default cutoff of the
+	// algorithm is used. If it is negative, the parallel algorithm is always
+	// used.
+	Cutoff int
+
+	// Descending sorts the array in descending order instead of ascending.
+	Descending bool
+}
+
+// Algorithm holds the per algorithm defaults, input checks and sort function.
+type algorithm struct {
+	cutoff int                                                  // Default cutoff
+	check  func([]int) error                                    // Checks the input is supported, if not nil
+	sort   func(context.Context, []int, Options) ([]int, error) // Runs the algorithm
+}
+
+// Algorithms supported by Sort.
+var algorithms = map[string]algorithm{
+	Bitonicsort: {
+		cutoff: 1 << 10,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return bitonicsort.SortWithOptions(ctx, arr, bitonicsort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	Bricksort: {
+		cutoff: 1 << 12,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return bricksort.SortWithOptions(ctx, arr, bricksort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	Mergesort: {
+		cutoff: 1 << 11,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return mergesort.SortWithOptions(ctx, arr, mergesort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	NaturalMergesort: {
+		cutoff: 1 << 11,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return mergesort.SortNaturalFuncWithOptions(ctx, arr, cmp.Less[int], mergesort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	InPlaceMergesort: {
+		cutoff: 1 << 11,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return mergesort.SortInPlaceFuncWithOptions(ctx, arr, cmp.Less[int], mergesort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	BottomUpMergesort: {
+		cutoff: 1 << 11,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return mergesort.SortBottomUpFuncWithOptions(ctx, arr, cmp.Less[int], mergesort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	Quicksort: {
+		cutoff: 1 << 11,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return quicksort.SortWithOptions(ctx, arr, quicksort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	Timsort: {
+		cutoff: 1 << 11,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return timsort.SortWithOptions(ctx, arr, timsort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+	// Radix sort sorts by decimal digits, and does not handle the sign
+	Radixsort: {
+		cutoff: 1 << 8,
+		check:  checkNonNegative,
+		sort: func(ctx context.Context, arr []int, opts Options) ([]int, error) {
+			return radixsort.SortWithOptions(ctx, arr, radixsort.MaxNumDigits(arr), radixsort.Options{
+				MaxProcs:   opts.MaxProcs,
+				Descending: opts.Descending,
+			})
+		},
+	},
+}
+
+// Sort sorts an array in place using the algorithm and parameters specified in
+// opts.
+//
+// It is SortContext with context.Background().
+func Sort(data []int, opts Options) ([]int, error) {
+	return SortContext(context.Background(), data, opts)
+}
+
+// SortContext sorts an array in place using the algorithm and parameters
+// specified in opts, and stops sorting when ctx is done.
+//
+// It returns the input array sorted, or an error if the options are not valid
+// or the input is not supported by the algorithm. If ctx is done before the
+// array is sorted, it returns ctx.Err().
+func SortContext(ctx context.Context, data []int, opts Options) ([]int, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = DefaultAlgorithm
+	}
+
+	alg, ok := algorithms[opts.Algorithm]
+	if !ok {
+		return data, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, opts.Algorithm)
+	}
+
+	if opts.MaxProcs < 0 {
+		return data, fmt.Errorf("%w: MaxProcs must be >= 0, got %d", ErrInvalidOptions, opts.MaxProcs)
+	}
+	if opts.Cutoff == 0 {
+		opts.Cutoff = alg.cutoff
+	}
+
+	if alg.check != nil {
+		if err := alg.check(data); err != nil {
+			return data, fmt.Errorf("%s: %w", opts.Algorithm, err)
+		}
+	}
+
+	// Small inputs are faster to sort sequentially
+	if len(data) < opts.Cutoff {
+		if opts.Descending {
+			sort.Sort(sort.Reverse(sort.IntSlice(data)))
+		} else {
+			sort.Ints(data)
+		}
+		return data, nil
+	}
+
+	data, err := alg.sort(ctx, data, opts)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return data, ctxErr
+		}
+		return data, fmt.Errorf("%w: %v", ErrUnsupportedInput, err)
+	}
+
+	return data, nil
+}
+
+// CheckNonNegative returns an ErrUnsupportedInput error if there are negative
+// values in the array.
+func checkNonNegative(arr []int) error {
+	for i, v := range arr {
+		if v < 0 {
+			return fmt.Errorf("%w: negative value %d at index %d", ErrUnsupportedInput, v, i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+// Test parallel sort facade
+package psort
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestSort checks Sort with all algorithms and a multitude of options.
+func TestSort(t *testing.T) {
+	in := []int{7, 6, 5, 45, 3, 26, 1, 10, 999, 4}
+	want := []int{1, 3, 4, 5, 6, 7, 10, 26, 45, 999}
+
+	for _, alg := range []string{"", Bitonicsort, Bricksort, Mergesort, NaturalMergesort, InPlaceMergesort, BottomUpMergesort, Quicksort, Radixsort, Timsort} {
+		for _, opts := range []Options{
+			{Algorithm: alg},
+			{Algorithm: alg, MaxProcs: 2, Cutoff: -1},
+			{Algorithm: alg, Cutoff: len(in) + 1},
+		} {
+			arrIn := make([]int, len(in))
+			copy(arrIn, in)
+
+			got, err := Sort(arrIn, opts)
+			if err != nil {
+				t.Errorf("Sort (%v, %+v) returned error %v", in, opts, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Sort (%v, %+v) == %v, want %v", in, opts, got, want)
+			}
+		}
+	}
+}
+
+// TestSortErrors checks Sort returns the expected errors.
+func TestSortErrors(t *testing.T) {
+	cases := []struct {
+		in   []int
+		opts Options
+		want error
+	}{
+		{[]int{1, 0}, Options{Algorithm: "heapsort"}, ErrUnknownAlgorithm},
+		{[]int{1, 0}, Options{MaxProcs: -1}, ErrInvalidOptions},
+		{[]int{1, -1}, Options{Algorithm: Radixsort}, ErrUnsupportedInput},
+	}
+
+	for _, c := range cases {
+		_, err := Sort(c.in, c.opts)
+
+		if !errors.Is(err, c.want) {
+			t.Errorf("Sort (%v, %+v) returned error %v, want %v", c.in, c.opts, err, c.want)
+		}
+	}
+}
+
+// TestSortContext checks SortContext returns the context error when it is done.
+func TestSortContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, alg := range []string{Bitonicsort, Bricksort, Mergesort, NaturalMergesort, InPlaceMergesort, BottomUpMergesort, Quicksort, Radixsort, Timsort} {
+		opts := Options{Algorithm: alg, Cutoff: -1}
+		_, err := SortContext(ctx, []int{3, 2, 1, 0}, opts)
+
+		if err != context.Canceled {
+			t.Errorf("SortContext (canceled, %+v) returned error %v, want %v", opts, err, context.Canceled)
+		}
+	}
+}
+
+// TestSortDescending checks Sort sorts in descending order with all algorithms.
+func TestSortDescending(t *testing.T) {
+	in := []int{7, 6, 5, 45, 3, 26, 1, 10, 999, 4}
+	want := []int{999, 45, 26, 10, 7, 6, 5, 4, 3, 1}
+
+	for _, alg := range []string{Bitonicsort, Bricksort, Mergesort, NaturalMergesort, InPlaceMergesort, BottomUpMergesort, Quicksort, Radixsort, Timsort} {
+		for _, cutoff := range []int{-1, len(in) + 1} {
+			opts := Options{Algorithm: alg, Cutoff: cutoff, Descending: true}
+			arrIn := make([]int, len(in))
+			copy(arrIn, in)
+
+			got, err := Sort(arrIn, opts)
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("Sort (%v, %+v) == %v, %v, want %v", in, opts, got, err, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package quicksort
+
+import (
+	"cmp"
+	"context"
+	"sync"
+
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// PartialSort sorts the first k positions of an array in place using the
+// parallel partial quicksort algorithm: arr[:k] ends up holding the k smallest
+// entries in ascending order, and arr[k:] the rest in unspecified order.
+//
+// Partial quicksort partitions the array like quicksort, but it drops the sides
+// of each partition that lie entirely past index k, and it only goes on
+// partially sorting the side that straddles it. Sides that lie entirely before
+// index k are sorted by quicksort, in parallel. It takes O(n + k log k)
+// expected work, instead of O(n log n) for Sort. Like Sort, it takes a scratch
+// buffer as long as the array unless Options.PartitionCutoff is larger than
+// it. It panics if k is negative or larger than the length of the array.
+// It returns the input array.
+func PartialSort(arr []int, k int) []int {
+	return PartialSortFunc(arr, k, cmp.Less[int])
+}
+
+// PartialSortFunc is like PartialSort, but for arrays of any type sorted by
+// less.
+func PartialSortFunc[T any](arr []T, k int, less func(a, b T) bool) []T {
+	arr, _ = PartialSortFuncWithOptions(context.Background(), arr, k, less, Options{})
+	return arr
+}
+
+// PartialSortFuncWithOptions is like PartialSortFunc, but it stops sorting when
+// ctx is done, and it is configured by opts. With opts.Descending, arr[:k] ends
+// up holding the k largest entries in descending order instead.
+//
+// Cancellation is checked like in SortContext.
+func PartialSortFuncWithOptions[T any](ctx context.Context, arr []T, k int, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	var wg sync.WaitGroup
+	n := len(arr)
+	if k < 0 || k > n {
+		panic("quicksort: k out of range")
+	}
+
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	pol := opts.policy()
+	var buf []T
+	if n >= pol.parallel {
+		buf = make([]T, n)
+	}
+
+	partialQuicksort(ctx, workers.New(opts.MaxProcs), arr, buf, 0, n-1, k, less, pol, maxDepth(n),
+		newPRNG(opts.Seed), &wg)
+	wg.Wait()
+
+	return arr, ctx.Err()
+}
+
+// PartialQuicksort sorts the entries of arr[p..r] that belong before index k.
+//
+// Sides of each partition that end before index k are handed to the pool to be
+// sorted by quicksort, and sides that start at index k or later are dropped,
+// so the loop only goes on with the side holding index k-1. Like quicksort, it
+// falls back to heapsort after depth partition steps.
+func partialQuicksort[T any](ctx context.Context, pool *workers.Pool, arr, buf []T, p int, r int, k int,
+	less func(a, b T) bool, pol policy, depth int, rng prng, wg *sync.WaitGroup) {
+	for p < r && p < k && ctx.Err() == nil {
+		if depth == 0 {
+			heapSort(arr[p:r+1], less)
+			return
+		}
+		depth--
+
+		lt, gt := partitionStep(pool, arr, buf, p, r, less, pol, &rng)
+
+		if gt+1 >= k {
+			// Only the left side holds entries before index k
+			r = lt - 1
+			continue
+		}
+
+		// The left side lies entirely before index k, so sort all of it
+		d, child := depth, rng.split()
+		lo, hi := p, lt-1
+		pool.Go(wg, func() { quicksort(ctx, pool, arr, buf, lo, hi, less, pol, d, child, wg) })
+		p = gt + 1
+	}
+}
@@ -0,0 +1,74 @@
+// Test parallel partial quicksort
+package quicksort
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestPartialSort checks PartialSortFuncWithOptions sorts the first k positions,
+// and keeps the other entries in the array, with different options.
+func TestPartialSort(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	less := func(a, b int) bool { return a < b }
+
+	for _, opts := range []Options{{}, {MaxProcs: 4, PartitionCutoff: 100}, {Partition: Lomuto, Pivot: Ninther},
+		{Descending: true, MaxProcs: 3}} {
+		for _, keys := range []int{3, 1 << 20} {
+			for _, n := range []int{0, 1, 10, 1000, 1 << 14} {
+				for _, k := range []int{0, 1, n / 100, n / 2, n - 1, n} {
+					if k < 0 || k > n {
+						continue
+					}
+					in := make([]int, n)
+					for i := range in {
+						in[i] = r.Intn(keys)
+					}
+					want := make([]int, n)
+					copy(want, in)
+					sort.Ints(want)
+					if opts.Descending {
+						sort.Sort(sort.Reverse(sort.IntSlice(want)))
+					}
+
+					got, err := PartialSortFuncWithOptions(context.Background(), in, k, less, opts)
+					if err != nil || !reflect.DeepEqual(got[:k], want[:k]) {
+						t.Errorf("PartialSortFuncWithOptions (n = %d, k = %d, %+v) returned error %v or a wrong prefix",
+							n, k, opts, err)
+						continue
+					}
+
+					// The rest of the array holds the other entries
+					rest := append([]int{}, got[k:]...)
+					sort.Ints(rest)
+					if opts.Descending {
+						sort.Sort(sort.Reverse(sort.IntSlice(rest)))
+					}
+					if !reflect.DeepEqual(rest, want[k:]) {
+						t.Errorf("PartialSortFuncWithOptions (n = %d, k = %d, %+v) lost entries", n, k, opts)
+					}
+				}
+			}
+		}
+	}
+
+	if got := PartialSort([]int{5, 3, 9, 1, 7}, 3); !reflect.DeepEqual(got[:3], []int{1, 3, 5}) {
+		t.Errorf("PartialSort ([5 3 9 1 7], 3) == %v, want [1 3 5 ...]", got)
+	}
+}
+
+// TestPartialSortContext checks PartialSortFuncWithOptions stops sorting when
+// the context is done.
+func TestPartialSortContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+	less := func(a, b int) bool { return a < b }
+	if _, err := PartialSortFuncWithOptions(ctx, in, 3, less, Options{}); err != context.Canceled {
+		t.Errorf("PartialSortFuncWithOptions (canceled) returned error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,115 @@
+package quicksort
+
+import "github.com/carlosgvaso/parallel-sort/workers"
+
+// PartitionGrain is the minimum number of entries of each chunk classified by
+// a goroutine in a parallel partition step.
+const partitionGrain int = 1 << 12
+
+// PartitionStep partitions arr[p..r] by parallelSplit, using buf[p..r] as
+// scratch space, if it has at least pol.parallel entries, and by split
+// otherwise. It returns the bounds lt and gt of the entries equal to the pivot.
+func partitionStep[T any](pool *workers.Pool, arr, buf []T, p int, r int, less func(a, b T) bool, pol policy,
+	rng *prng) (int, int) {
+	if r-p+1 >= pol.parallel {
+		return parallelSplit(pool, arr, buf, p, r, less, pol, rng)
+	}
+	return split(arr, p, r, less, pol, rng)
+}
+
+// ParallelSplit is like split, but the partition step is split between
+// goroutines from the pool by parallelPartition, using buf[p..r] as scratch
+// space.
+//
+// The Lomuto scheme still only places the pivot between the entries that do
+// not sort after it and the entries that do.
+func parallelSplit[T any](pool *workers.Pool, arr, buf []T, p int, r int, less func(a, b T) bool, pol policy,
+	rng *prng) (int, int) {
+	index := choosePivot(arr, p, r, less, pol.pivot, rng)
+	pivot := arr[index]
+
+	if pol.partition == Lomuto {
+		arr[index], arr[r] = arr[r], arr[index]
+		n0, _ := parallelPartition(pool, arr[p:r], buf[p:r], func(v T) int {
+			if less(pivot, v) {
+				return 2
+			}
+			return 0
+		})
+
+		q := p + n0
+		arr[q], arr[r] = arr[r], arr[q]
+		return q, q
+	}
+
+	n0, n1 := parallelPartition(pool, arr[p:r+1], buf[p:r+1], func(v T) int {
+		switch {
+		case less(v, pivot):
+			return 0
+		case less(pivot, v):
+			return 2
+		default:
+			return 1
+		}
+	})
+	return p + n0, p + n0 + n1 - 1
+}
+
+// ParallelPartition moves the entries of s in three groups, by their class 0, 1
+// or 2, using buf as scratch space of the same length. The entries of each
+// group keep their order in s.
+//
+// It is a prefix-sum partition: s is split in chunks of at least partitionGrain
+// entries, one per goroutine in the pool at most. The goroutines count the
+// entries of each class in their chunks, the prefix sums of the counts give
+// the offset of each chunk in each group, and then the goroutines scatter their
+// chunks to buf at those offsets. Finally, buf is copied back to s in parallel.
+// Class is called twice for each entry.
+//
+// It returns the number of entries of class 0 and 1.
+func parallelPartition[T any](pool *workers.Pool, s, buf []T, class func(v T) int) (int, int) {
+	n := len(s)
+	chunks := workers.Chunks(n, partitionGrain, pool.Size())
+	size := (n + chunks - 1) / chunks
+	chunk := func(c int) []T {
+		return s[min(c*size, n):min((c+1)*size, n)]
+	}
+
+	// Count the entries of each class in each chunk
+	counts := make([][3]int, chunks)
+	pool.For(chunks, 1, func(lo, hi int) {
+		for c := lo; c < hi; c++ {
+			for _, v := range chunk(c) {
+				counts[c][class(v)]++
+			}
+		}
+	})
+
+	// Turn the counts into the offsets of each chunk in each group
+	var totals [3]int
+	for k := range totals {
+		for c := range counts {
+			counts[c][k], totals[k] = totals[k], totals[k]+counts[c][k]
+		}
+	}
+	for c := range counts {
+		counts[c][1] += totals[0]
+		counts[c][2] += totals[0] + totals[1]
+	}
+
+	pool.For(chunks, 1, func(lo, hi int) {
+		for c := lo; c < hi; c++ {
+			offsets := counts[c]
+			for _, v := range chunk(c) {
+				k := class(v)
+				buf[offsets[k]] = v
+				offsets[k]++
+			}
+		}
+	})
+	pool.For(n, partitionGrain, func(lo, hi int) {
+		copy(s[lo:hi], buf[lo:hi])
+	})
+
+	return totals[0], totals[1]
+}
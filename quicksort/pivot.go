@@ -0,0 +1,97 @@
+package quicksort
+
+import "math/rand"
+
+// NintherCutoff is the length under which Ninther and PseudoMedianOfNine choose
+// the median of three entries instead, as nine samples cost more than they
+// save on short subarrays.
+const nintherCutoff int = 40
+
+// Prng is a SplitMix64 pseudo-random number generator.
+//
+// It is a single word of state, so each call of quicksort owns a copy, and no
+// goroutine contends on a shared source.
+type prng struct {
+	state uint64
+}
+
+// NewPRNG returns a generator seeded by seed, or by a random seed if it is 0.
+func newPRNG(seed uint64) prng {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return prng{state: seed}
+}
+
+// Next returns the next pseudo-random number of g.
+func (g *prng) next() uint64 {
+	g.state += 0x9e3779b97f4a7c15
+	z := g.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Intn returns a pseudo-random number in [0, n).
+func (g *prng) intn(n int) int {
+	return int(g.next() % uint64(n))
+}
+
+// Split returns a new generator seeded by the next number of g. The sequence of
+// generators split from g only depends on the seed of g.
+func (g *prng) split() prng {
+	return prng{state: g.next()}
+}
+
+// ChoosePivot returns the index of the pivot of arr[p..r] chosen by strategy.
+func choosePivot[T any](arr []T, p int, r int, less func(a, b T) bool, strategy PivotStrategy,
+	rng *prng) int {
+	n := r - p + 1
+	m := p + n/2
+
+	switch strategy {
+	case MedianOfThree:
+		return median3(arr, p, m, r, less)
+
+	case Ninther:
+		if n < nintherCutoff {
+			return median3(arr, p, m, r, less)
+		}
+		s := n / 8
+		return median3(arr,
+			median3(arr, p, p+s, p+2*s, less),
+			median3(arr, m-s, m, m+s, less),
+			median3(arr, r-2*s, r-s, r, less), less)
+
+	case PseudoMedianOfNine:
+		if n < nintherCutoff {
+			return median3(arr, p, m, r, less)
+		}
+		var samples [9]int
+		for i := range samples {
+			samples[i] = p + rng.intn(n)
+		}
+		return median3(arr,
+			median3(arr, samples[0], samples[1], samples[2], less),
+			median3(arr, samples[3], samples[4], samples[5], less),
+			median3(arr, samples[6], samples[7], samples[8], less), less)
+
+	default:
+		return p + rng.intn(n)
+	}
+}
+
+// Median3 returns the index of the median of arr[i], arr[j] and arr[k].
+func median3[T any](arr []T, i, j, k int, less func(a, b T) bool) int {
+	if less(arr[j], arr[i]) {
+		i, j = j, i
+	}
+	// Now arr[i] <= arr[j]
+	if less(arr[k], arr[j]) {
+		if less(arr[k], arr[i]) {
+			return i
+		}
+		return k
+	}
+	return j
+}
@@ -1,45 +1,366 @@
 // Package quicksort provides a parallel quick sort implementation to sort
-// integer arrays.
+// arrays of any ordered type.
+//
+// Like introsort, subarrays that take more than about 2·log2(n) partition
+// steps to sort are sorted by heapsort instead, so sorting takes O(n log n)
+// work even for the inputs that make the choice of pivots go wrong.
+//
+// Quicksort is not stable: equal entries may be reordered.
 package quicksort
 
 import (
-	"math/rand"
+	"cmp"
+	"context"
+	"math/bits"
 	"sync"
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
+	"github.com/carlosgvaso/parallel-sort/workers"
 )
 
+// DefaultPartitionCutoff is the length under which subarrays are partitioned
+// by a single goroutine when Options.PartitionCutoff is 0.
+const DefaultPartitionCutoff = 1 << 16
+
+// PartitionScheme selects how each partition step splits a subarray around the
+// pivot.
+type PartitionScheme int
+
+const (
+	// ThreeWay splits the subarray in the entries that sort before the pivot,
+	// the entries equal to it, and the entries that sort after it, following
+	// the Dutch national flag algorithm. Entries equal to the pivot are not
+	// sorted further, so arrays with many duplicates take O(n log k) work for
+	// k distinct keys.
+	ThreeWay PartitionScheme = iota
+
+	// Lomuto splits the subarray in the entries that do not sort after the
+	// pivot, and the entries that do. It is the original scheme of this
+	// package. If all the entries are equal, each step only places the pivot,
+	// so the array ends up sorted by the heapsort fallback.
+	Lomuto
+)
+
+// PivotStrategy selects how each partition step chooses its pivot.
+type PivotStrategy int
+
+const (
+	// Random chooses an entry at random.
+	Random PivotStrategy = iota
+
+	// MedianOfThree chooses the median of the first, middle and last entries.
+	MedianOfThree
+
+	// Ninther chooses Tukey's ninther: the median of the medians of three
+	// groups of three entries spread evenly over the subarray.
+	Ninther
+
+	// PseudoMedianOfNine chooses the median of the medians of three groups of
+	// three entries at random.
+	PseudoMedianOfNine
+)
+
+// Options configures SortWithOptions.
+type Options struct {
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0 or less, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
+
+	// Descending sorts the array in descending order instead of ascending.
+	Descending bool
+
+	// Partition is the partition scheme. The default is ThreeWay.
+	Partition PartitionScheme
+
+	// Pivot is the pivot strategy. The default is Random.
+	Pivot PivotStrategy
+
+	// Seed seeds the random choices of pivots. Each goroutine has its own
+	// generator, derived from the generator of the goroutine that handed it
+	// its subarray, so the same seed and options give the same partition
+	// steps for any MaxProcs. If it is 0, a random seed is used.
+	Seed uint64
+
+	// PartitionCutoff is the length under which subarrays are partitioned by
+	// a single goroutine, instead of splitting the partition step between
+	// goroutines. If it is 0, DefaultPartitionCutoff is used. If it is
+	// negative, partition steps are always split.
+	//
+	// Split partition steps copy the entries out of place, so arrays of at
+	// least PartitionCutoff entries take a scratch buffer of their length. Set
+	// it to math.MaxInt to partition in place, with O(log n) extra space.
+	PartitionCutoff int
+}
+
+// Policy holds the choices of each partition step.
+type policy struct {
+	partition PartitionScheme
+	pivot     PivotStrategy
+	parallel  int // Length from which partition steps are split
+}
+
+// Policy returns the partition policy selected by opts.
+func (opts Options) policy() policy {
+	pol := policy{partition: opts.Partition, pivot: opts.Pivot, parallel: opts.PartitionCutoff}
+
+	if pol.parallel == 0 {
+		pol.parallel = DefaultPartitionCutoff
+	}
+	if pol.parallel < 2 {
+		pol.parallel = 2
+	}
+	return pol
+}
+
+func init() {
+	sorter.Register("quicksort", sorter.Func(SortContext))
+	sorter.RegisterBaseline("quicksort", sorter.Sequential(SortSequential))
+
+	sorter.Register("lomutoquicksort", sorter.Func(func(ctx context.Context, arr []int) ([]int, error) {
+		return SortWithOptions(ctx, arr, Options{Partition: Lomuto})
+	}))
+	sorter.RegisterBaseline("lomutoquicksort", sorter.Sequential(func(arr []int) []int {
+		sequentialQuicksort(arr, 0, len(arr)-1, cmp.Less[int], policy{partition: Lomuto}, maxDepth(len(arr)),
+			newPRNG(0))
+		return arr
+	}))
+}
+
 // Sort sorts an array in place using the parallel quicksort algorithm.
 //
 // It takes an array as an input.
 // It returns the input array sorted.
 func Sort(arr []int) []int {
+	return SortOrdered(arr)
+}
+
+// SortContext is like Sort, but it stops sorting when ctx is done.
+//
+// Cancellation is checked before each partition step, and no more goroutines
+// are started after ctx is done. In that case, it returns ctx.Err() and the
+// array is left partially sorted.
+func SortContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortWithOptions(ctx, arr, Options{})
+}
+
+// SortWithOptions is like SortContext, but it is configured by opts.
+func SortWithOptions(ctx context.Context, arr []int, opts Options) ([]int, error) {
+	return SortOrderedWithOptions(ctx, arr, opts)
+}
+
+// SortOrdered sorts an array of any ordered type in place using the parallel
+// quicksort algorithm.
+//
+// It returns the input array sorted.
+func SortOrdered[T cmp.Ordered](arr []T) []T {
+	arr, _ = SortOrderedWithOptions(context.Background(), arr, Options{})
+	return arr
+}
+
+// SortOrderedWithOptions is like SortWithOptions, but for arrays of any
+// ordered type.
+func SortOrderedWithOptions[T cmp.Ordered](ctx context.Context, arr []T, opts Options) ([]T, error) {
+	return SortFuncWithOptions(ctx, arr, cmp.Less[T], opts)
+}
+
+// SortFunc sorts an array of any type in place using the parallel quicksort
+// algorithm.
+//
+// less reports whether a sorts before b. It must be a strict weak ordering, and
+// it is called from multiple goroutines at the same time.
+// It returns the input array sorted.
+func SortFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortFuncWithOptions is like SortWithOptions, but for arrays of any type
+// sorted by less.
+func SortFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool, opts Options) ([]T, error) {
 	var wg sync.WaitGroup // Wait group to synchronize parallel goroutines
 	var n int = len(arr)  // Length of the array
 
+	// Sort in descending order by swapping the arguments of less
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	// Parallel partition steps need scratch space. Concurrent steps work on
+	// disjoint subarrays, so they share a buffer of the length of arr.
+	pol := opts.policy()
+	var buf []T
+	if n >= pol.parallel {
+		buf = make([]T, n)
+	}
+
 	// Run quicksort
-	wg.Add(1)
-	quicksort(arr, 0, n-1, &wg)
+	quicksort(ctx, workers.New(opts.MaxProcs), arr, buf, 0, n-1, less, pol, maxDepth(n), newPRNG(opts.Seed), &wg)
 	wg.Wait()
 
+	return arr, ctx.Err()
+}
+
+// Quicksort is a regular quicksort implementation parallelized by goroutines at
+// each recursive call.
+//
+// The smaller side of each partition is handed to the pool, and the larger
+// side is sorted in the same call. This bounds the recursion depth to log(n)
+// when the pool runs the smaller side in the calling goroutine.
+//
+// Depth is the number of partition steps left before arr[p..r] is sorted by
+// heapsort instead. Rng is the generator of this call, and the smaller sides
+// get generators split from it. Buf is the scratch space of partitionStep.
+func quicksort[T any](ctx context.Context, pool *workers.Pool, arr, buf []T, p int, r int,
+	less func(a, b T) bool, pol policy, depth int, rng prng, wg *sync.WaitGroup) {
+	for p < r && ctx.Err() == nil {
+		if depth == 0 {
+			heapSort(arr[p:r+1], less)
+			return
+		}
+		depth--
+
+		lt, gt := partitionStep(pool, arr, buf, p, r, less, pol, &rng)
+
+		d, child := depth, rng.split()
+		if lt-p < r-gt {
+			lo, hi := p, lt-1
+			pool.Go(wg, func() { quicksort(ctx, pool, arr, buf, lo, hi, less, pol, d, child, wg) })
+			p = gt + 1
+		} else {
+			lo, hi := gt+1, r
+			pool.Go(wg, func() { quicksort(ctx, pool, arr, buf, lo, hi, less, pol, d, child, wg) })
+			r = lt - 1
+		}
+	}
+}
+
+// MaxDepth returns the number of partition steps allowed to sort an array of n
+// entries before falling back to heapsort: 2·ceil(log2(n+1)).
+func maxDepth(n int) int {
+	return 2 * bits.Len(uint(n))
+}
+
+// HeapSort sorts s in place using the heapsort algorithm, which takes
+// O(n log n) work for any input.
+func heapSort[T any](s []T, less func(a, b T) bool) {
+	n := len(s)
+
+	// Build a max-heap
+	for i := n/2 - 1; i >= 0; i-- {
+		siftDown(s, i, n, less)
+	}
+
+	// Move the largest entry of the heap after it, and shrink the heap
+	for end := n - 1; end > 0; end-- {
+		s[0], s[end] = s[end], s[0]
+		siftDown(s, 0, end, less)
+	}
+}
+
+// SiftDown moves s[root] down the max-heap s[:n] until it is not smaller than
+// its children.
+func siftDown[T any](s []T, root, n int, less func(a, b T) bool) {
+	for {
+		child := 2*root + 1
+		if child >= n {
+			return
+		}
+		if child+1 < n && less(s[child], s[child+1]) {
+			child++
+		}
+		if !less(s[root], s[child]) {
+			return
+		}
+		s[root], s[child] = s[child], s[root]
+		root = child
+	}
+}
+
+/* Sequential */
+
+// SortSequential sorts an array in place using the sequential quicksort
+// algorithm, with the same partition as Sort and a single goroutine.
+//
+// It is the baseline to measure the speedup of Sort.
+// It returns the input array sorted.
+func SortSequential(arr []int) []int {
+	sequentialQuicksort(arr, 0, len(arr)-1, cmp.Less[int], policy{}, maxDepth(len(arr)), newPRNG(0))
 	return arr
 }
 
-// Quicksort is a regular quicksort implementation with random pivot and
-// parallelized by goroutines at each recursive call
-func quicksort(arr []int, p int, r int, wg *sync.WaitGroup) {
-	defer wg.Done()
+// SequentialQuicksort is like quicksort, but it sorts the smaller side of each
+// partition by a recursive call instead of handing it to a pool.
+func sequentialQuicksort[T any](arr []T, p int, r int, less func(a, b T) bool, pol policy, depth int,
+	rng prng) {
+	for p < r {
+		if depth == 0 {
+			heapSort(arr[p:r+1], less)
+			return
+		}
+		depth--
+
+		lt, gt := split(arr, p, r, less, pol, &rng)
+
+		if lt-p < r-gt {
+			sequentialQuicksort(arr, p, lt-1, less, pol, depth, rng.split())
+			p = gt + 1
+		} else {
+			sequentialQuicksort(arr, gt+1, r, less, pol, depth, rng.split())
+			r = lt - 1
+		}
+	}
+}
+
+// Split partitions arr[p..r] around a pivot chosen by pol, with the scheme of
+// pol. It returns the bounds lt and gt of the entries equal to the pivot, which
+// are already in their final position: arr[p..lt-1] sort before arr[lt..gt],
+// and arr[gt+1..r] after.
+//
+// The Lomuto scheme only places the pivot itself, so lt == gt.
+func split[T any](arr []T, p int, r int, less func(a, b T) bool, pol policy, rng *prng) (int, int) {
+	index := choosePivot(arr, p, r, less, pol.pivot, rng)
+
+	if pol.partition == Lomuto {
+		q := partition(arr, p, r, index, less)
+		return q, q
+	}
+	return partitionThreeWay(arr, p, r, index, less)
+}
 
-	if p < r {
-		q := partition(arr, p, r)
+// PartitionThreeWay splits arr[p..r] around the pivot arr[index] in the entries
+// that sort before it, the entries equal to it, and the entries that sort after
+// it.
+//
+// It returns the bounds lt and gt of the entries equal to the pivot.
+func partitionThreeWay[T any](arr []T, p int, r int, index int, less func(a, b T) bool) (int, int) {
+	pivot := arr[index]
+	lt, i, gt := p, p, r
 
-		wg.Add(2)
-		go quicksort(arr, p, q-1, wg)
-		go quicksort(arr, q+1, r, wg)
+	// Invariant: arr[p..lt-1] < pivot, arr[lt..i-1] == pivot, and
+	// arr[gt+1..r] > pivot
+	for i <= gt {
+		switch {
+		case less(arr[i], pivot):
+			arr[lt], arr[i] = arr[i], arr[lt]
+			lt++
+			i++
+		case less(pivot, arr[i]):
+			arr[i], arr[gt] = arr[gt], arr[i]
+			gt--
+		default:
+			i++
+		}
 	}
+
+	return lt, gt
 }
 
-// Partition splits the input array using a randomized choice of a pivot.
-func partition(arr []int, p int, r int) int {
-	index := rand.Intn(r-p) + p
+// Partition splits arr[p..r] around the pivot arr[index], and returns the final
+// position of the pivot.
+//
+// less reports whether a sorts before b.
+func partition[T any](arr []T, p int, r int, index int, less func(a, b T) bool) int {
 	pivot := arr[index]
 	arr[index] = arr[r]
 	arr[r] = pivot
@@ -48,7 +369,7 @@ func partition(arr []int, p int, r int) int {
 	i := p
 
 	for i < r {
-		if arr[i] <= x {
+		if !less(x, arr[i]) {
 			j++
 
 			tmp := arr[j]
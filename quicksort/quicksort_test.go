@@ -2,8 +2,15 @@
 package quicksort
 
 import (
+	"context"
+	"math"
+	"math/rand"
 	"reflect"
+	"sort"
+	"sync"
 	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/workers"
 )
 
 // TestSort checks Sort with a multitude of input arrays.
@@ -29,3 +36,353 @@ func TestSort(t *testing.T) {
 		}
 	}
 }
+
+// TestSortContext checks SortContext stops sorting when the context is done.
+func TestSortContext(t *testing.T) {
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	arrIn := make([]int, len(in))
+	copy(arrIn, in)
+	if _, err := SortContext(ctx, arrIn); err != context.Canceled {
+		t.Errorf("SortContext (canceled, %v) returned error %v, want %v", in, err, context.Canceled)
+	}
+
+	copy(arrIn, in)
+	got, err := SortContext(context.Background(), arrIn)
+	if err != nil {
+		t.Errorf("SortContext (%v) returned error %v", in, err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortContext (%v) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortOrdered checks SortOrdered with arrays of other ordered types.
+func TestSortOrdered(t *testing.T) {
+	floats := SortOrdered([]float64{2.5, -1, 0, 3.25, -7.5, 2.5})
+	if want := []float64{-7.5, -1, 0, 2.5, 2.5, 3.25}; !reflect.DeepEqual(floats, want) {
+		t.Errorf("SortOrdered ([]float64) == %v, want %v", floats, want)
+	}
+
+	strs := SortOrdered([]string{"pear", "apple", "fig", "", "banana"})
+	if want := []string{"", "apple", "banana", "fig", "pear"}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("SortOrdered ([]string) == %v, want %v", strs, want)
+	}
+
+	uints := SortOrdered([]uint64{1 << 63, 7, 0, 1<<64 - 1, 42})
+	if want := []uint64{0, 7, 42, 1 << 63, 1<<64 - 1}; !reflect.DeepEqual(uints, want) {
+		t.Errorf("SortOrdered ([]uint64) == %v, want %v", uints, want)
+	}
+}
+
+// TestSortFunc checks SortFunc with an array of records.
+func TestSortFunc(t *testing.T) {
+	type record struct {
+		name string
+		age  int
+	}
+	in := []record{{"carol", 35}, {"alice", 30}, {"dave", 20}, {"bob", 25}, {"erin", 40}}
+	want := []record{{"dave", 20}, {"bob", 25}, {"alice", 30}, {"carol", 35}, {"erin", 40}}
+
+	got := SortFunc(in, func(a, b record) bool { return a.age < b.age })
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortFunc (%v, by age) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortDescending checks SortWithOptions sorts in descending order.
+func TestSortDescending(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{7, 6, 5, 4, 3, 2, 1, 0}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4, 5}, []int{7, 6, 5, 5, 4, 3, 2, 1, 0}},
+		{[]int{42, 7, 999, 0, 130}, []int{999, 130, 42, 7, 0}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := SortWithOptions(context.Background(), arrIn, Options{Descending: true})
+
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortWithOptions (%v, descending) == %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+// TestSortPartition checks SortWithOptions with both partition schemes, and
+// arrays with few distinct keys.
+func TestSortPartition(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, scheme := range []PartitionScheme{ThreeWay, Lomuto} {
+		for _, keys := range []int{1, 2, 10, 1 << 20} {
+			in := make([]int, 5000)
+			for i := range in {
+				in[i] = r.Intn(keys)
+			}
+			want := make([]int, len(in))
+			copy(want, in)
+			sort.Ints(want)
+
+			got, err := SortWithOptions(context.Background(), in, Options{Partition: scheme, MaxProcs: 4})
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("SortWithOptions (%d keys, scheme %d) returned error %v or a wrong order", keys, scheme, err)
+			}
+		}
+	}
+}
+
+// TestPartitionThreeWay checks partitionThreeWay places the entries equal to
+// the pivot between the smaller and the larger ones.
+func TestPartitionThreeWay(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{2, 3, 10, 1000} {
+		arr := make([]int, n)
+		for i := range arr {
+			arr[i] = r.Intn(5)
+		}
+
+		lt, gt := partitionThreeWay(arr, 0, n-1, n/2, func(a, b int) bool { return a < b })
+
+		for i, v := range arr {
+			if (i < lt && v >= arr[lt]) || (i >= lt && i <= gt && v != arr[lt]) || (i > gt && v <= arr[lt]) {
+				t.Errorf("partitionThreeWay (n = %d) == %d, %d with %v", n, lt, gt, arr)
+				break
+			}
+		}
+	}
+}
+
+// TestSortPivot checks SortWithOptions with every pivot strategy and partition
+// scheme, and inputs that are sorted, reversed, random or with few keys.
+func TestSortPivot(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	gens := map[string]func(i, n int) int{
+		"sorted":   func(i, n int) int { return i },
+		"reversed": func(i, n int) int { return n - i },
+		"random":   func(i, n int) int { return r.Intn(n) },
+		"few keys": func(i, n int) int { return r.Intn(3) },
+		"organ":    func(i, n int) int { return min(i, n-i) },
+	}
+
+	for _, pivot := range []PivotStrategy{Random, MedianOfThree, Ninther, PseudoMedianOfNine} {
+		for _, scheme := range []PartitionScheme{ThreeWay, Lomuto} {
+			for name, gen := range gens {
+				for _, n := range []int{2, 39, 40, 5000} {
+					in := make([]int, n)
+					for i := range in {
+						in[i] = gen(i, n)
+					}
+					want := make([]int, n)
+					copy(want, in)
+					sort.Ints(want)
+
+					opts := Options{MaxProcs: 4, Pivot: pivot, Partition: scheme, Seed: 7}
+					got, err := SortWithOptions(context.Background(), in, opts)
+					if err != nil || !reflect.DeepEqual(got, want) {
+						t.Errorf("SortWithOptions (%s, n = %d, %+v) returned error %v or a wrong order",
+							name, n, opts, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestSortSeed checks the same seed gives the same order of equal entries for
+// any MaxProcs, so the partition steps are the same.
+func TestSortSeed(t *testing.T) {
+	type pair struct {
+		key     int
+		payload int
+	}
+	less := func(a, b pair) bool { return a.key < b.key }
+	r := rand.New(rand.NewSource(1))
+
+	in := make([]pair, 1<<14)
+	for i := range in {
+		in[i] = pair{r.Intn(100), i}
+	}
+
+	for _, pivot := range []PivotStrategy{Random, PseudoMedianOfNine} {
+		var want []pair
+		for _, procs := range []int{1, 2, 8} {
+			got := make([]pair, len(in))
+			copy(got, in)
+			opts := Options{MaxProcs: procs, Pivot: pivot, Partition: Lomuto, Seed: 42, PartitionCutoff: 1000}
+			if _, err := SortFuncWithOptions(context.Background(), got, less, opts); err != nil {
+				t.Fatalf("SortFuncWithOptions (%+v) returned error %v", opts, err)
+			}
+
+			if want == nil {
+				want = got
+			} else if !reflect.DeepEqual(got, want) {
+				t.Errorf("SortFuncWithOptions (%+v) ordered equal entries differently than with MaxProcs 1", opts)
+			}
+		}
+	}
+}
+
+// TestMedian3 checks median3 with every order of three entries.
+func TestMedian3(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+
+	for _, arr := range [][]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}, {1, 1, 0}, {1, 1, 1}} {
+		if got := median3(arr, 0, 1, 2, less); arr[got] != sortedMiddle(arr) {
+			t.Errorf("median3 (%v) == %d, want the index of %d", arr, got, sortedMiddle(arr))
+		}
+	}
+}
+
+// SortedMiddle returns the median of three entries.
+func sortedMiddle(arr []int) int {
+	s := append([]int{}, arr...)
+	sort.Ints(s)
+	return s[1]
+}
+
+// TestSortPartitionCutoff checks SortWithOptions with partition steps split
+// between goroutines from different lengths, with both partition schemes.
+func TestSortPartitionCutoff(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, cutoff := range []int{-1, 100, 1 << 13, math.MaxInt} {
+		for _, scheme := range []PartitionScheme{ThreeWay, Lomuto} {
+			for _, keys := range []int{3, 1 << 20} {
+				in := make([]int, 1<<15)
+				for i := range in {
+					in[i] = r.Intn(keys)
+				}
+				want := make([]int, len(in))
+				copy(want, in)
+				sort.Ints(want)
+
+				opts := Options{MaxProcs: 4, Partition: scheme, PartitionCutoff: cutoff}
+				got, err := SortWithOptions(context.Background(), in, opts)
+				if err != nil || !reflect.DeepEqual(got, want) {
+					t.Errorf("SortWithOptions (%d keys, %+v) returned error %v or a wrong order", keys, opts, err)
+				}
+			}
+		}
+	}
+}
+
+// TestParallelPartition checks parallelPartition groups the entries by class,
+// keeping their order, for any number of goroutines.
+func TestParallelPartition(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, procs := range []int{1, 3, 8} {
+		for _, n := range []int{1, 10, partitionGrain, 5*partitionGrain + 7} {
+			s := make([]int, n)
+			for i := range s {
+				s[i] = r.Intn(1000)
+			}
+			class := func(v int) int { return v % 3 }
+
+			// The expected groups keep the order of s
+			var want []int
+			counts := [3]int{}
+			for k := 0; k < 3; k++ {
+				for _, v := range s {
+					if class(v) == k {
+						want = append(want, v)
+						counts[k]++
+					}
+				}
+			}
+
+			n0, n1 := parallelPartition(workers.New(procs), s, make([]int, n), class)
+
+			if n0 != counts[0] || n1 != counts[1] || !reflect.DeepEqual(s, want) {
+				t.Errorf("parallelPartition (n = %d, procs = %d) == %d, %d, want %d, %d", n, procs, n0, n1,
+					counts[0], counts[1])
+			}
+		}
+	}
+}
+
+// TestSortDepthLimit checks quicksort falls back to heapsort when it runs out
+// of partition steps, including on the first step.
+func TestSortDepthLimit(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	less := func(a, b int) bool { return a < b }
+	pool := workers.New(4)
+
+	for _, depth := range []int{0, 1, 3} {
+		for _, n := range []int{1, 2, 9, 1000} {
+			in := make([]int, n)
+			for i := range in {
+				in[i] = r.Intn(n)
+			}
+			want := make([]int, n)
+			copy(want, in)
+			sort.Ints(want)
+
+			var wg sync.WaitGroup
+			quicksort(context.Background(), pool, in, nil, 0, n-1, less, Options{}.policy(), depth, newPRNG(1), &wg)
+			wg.Wait()
+
+			if !reflect.DeepEqual(in, want) {
+				t.Errorf("quicksort (n = %d, depth = %d) == %v, want %v", n, depth, in, want)
+			}
+		}
+	}
+
+	// Lomuto partitions of equal entries are maximally unbalanced, so this
+	// array is sorted by heapsort after maxDepth steps
+	in := make([]int, 1<<15)
+	got, err := SortWithOptions(context.Background(), in, Options{Partition: Lomuto})
+	if err != nil || !sort.IntsAreSorted(got) {
+		t.Errorf("SortWithOptions (%d equal entries, Lomuto) returned error %v or a wrong order", len(in), err)
+	}
+}
+
+// TestHeapSort checks heapSort with random arrays.
+func TestHeapSort(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 3, 10, 1000} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(n + 1)
+		}
+		want := make([]int, n)
+		copy(want, in)
+		sort.Ints(want)
+
+		heapSort(in, func(a, b int) bool { return a < b })
+
+		if !reflect.DeepEqual(in, want) {
+			t.Errorf("heapSort (n = %d) == %v, want %v", n, in, want)
+		}
+	}
+}
+
+// TestSortSequential checks the sequential baseline sorts like Sort.
+func TestSortSequential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 7, 100, 1000} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(n + 1)
+		}
+		want := make([]int, n)
+		copy(want, in)
+		sort.Ints(want)
+
+		if got := SortSequential(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SortSequential (n = %d) == %v, want %v", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package quicksort
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// Select returns the k-th smallest entry of an array, counting from 0, using
+// the parallel quickselect algorithm.
+//
+// The array is reordered in place like NthElement, and it takes the same scratch
+// space. It panics if k is not a valid index of the array.
+func Select(arr []int, k int) int {
+	return SelectFunc(arr, k, cmp.Less[int])
+}
+
+// SelectFunc is like Select, but for arrays of any type sorted by less.
+func SelectFunc[T any](arr []T, k int, less func(a, b T) bool) T {
+	v, _ := SelectFuncWithOptions(context.Background(), arr, k, less, Options{})
+	return v
+}
+
+// SelectFuncWithOptions is like SelectFunc, but it stops when ctx is done, and
+// it is configured by opts. With opts.Descending, it returns the k-th largest
+// entry instead.
+//
+// If ctx is done first, it returns the zero value and ctx.Err().
+func SelectFuncWithOptions[T any](ctx context.Context, arr []T, k int, less func(a, b T) bool,
+	opts Options) (T, error) {
+	if _, err := NthElementFuncWithOptions(ctx, arr, k, less, opts); err != nil {
+		var zero T
+		return zero, err
+	}
+	return arr[k], nil
+}
+
+// NthElement reorders an array in place so arr[k] is the entry that would be
+// there if the array was sorted, the entries before it do not sort after it,
+// and the entries after it do not sort before it.
+//
+// Quickselect partitions the array like quicksort, but it only goes on with the
+// side of each partition that holds index k, so it takes O(n) expected work.
+// Partition steps of long subarrays are split between goroutines like in
+// quicksort, which takes a scratch buffer as long as the array if it has at
+// least DefaultPartitionCutoff entries. Use NthElementFuncWithOptions with
+// Options.PartitionCutoff set to math.MaxInt to work in place with O(1) extra
+// space. It panics if k is not a valid index of the array.
+// It returns the input array.
+func NthElement(arr []int, k int) []int {
+	return NthElementFunc(arr, k, cmp.Less[int])
+}
+
+// NthElementFunc is like NthElement, but for arrays of any type sorted by less.
+func NthElementFunc[T any](arr []T, k int, less func(a, b T) bool) []T {
+	arr, _ = NthElementFuncWithOptions(context.Background(), arr, k, less, Options{})
+	return arr
+}
+
+// NthElementFuncWithOptions is like NthElementFunc, but it stops when ctx is
+// done, and it is configured by opts.
+//
+// Cancellation is checked before each partition step.
+func NthElementFuncWithOptions[T any](ctx context.Context, arr []T, k int, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	n := len(arr)
+	if k < 0 || k >= n {
+		panic("quicksort: k out of range")
+	}
+
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	pol := opts.policy()
+	var buf []T
+	if n >= pol.parallel {
+		buf = make([]T, n)
+	}
+
+	quickselect(ctx, workers.New(opts.MaxProcs), arr, buf, 0, n-1, k, less, pol, maxDepth(n), newPRNG(opts.Seed))
+	return arr, ctx.Err()
+}
+
+// TopK returns the k smallest entries of an array in ascending order.
+//
+// It moves them to the start of the array, sorted, by PartialSort, so it takes
+// O(n + k log k) expected work, and the same scratch space. The rest of the
+// array is left in unspecified order. It panics if k is negative or larger
+// than the length of the array.
+// It returns arr[:k].
+func TopK(arr []int, k int) []int {
+	return TopKFunc(arr, k, cmp.Less[int])
+}
+
+// TopKFunc is like TopK, but for arrays of any type sorted by less.
+func TopKFunc[T any](arr []T, k int, less func(a, b T) bool) []T {
+	arr, _ = TopKFuncWithOptions(context.Background(), arr, k, less, Options{})
+	return arr
+}
+
+// TopKFuncWithOptions is like TopKFunc, but it stops when ctx is done, and it is
+// configured by opts. With opts.Descending, it returns the k largest entries in
+// descending order instead.
+func TopKFuncWithOptions[T any](ctx context.Context, arr []T, k int, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	arr, err := PartialSortFuncWithOptions(ctx, arr, k, less, opts)
+	return arr[:k], err
+}
+
+// Quickselect moves the entry of arr[p..r] that belongs at index k to its sorted
+// position, with the entries that do not sort after it before it, and the
+// entries that do not sort before it after it.
+//
+// It partitions like quicksort, but it does not recurse: it loops on the side
+// holding k. Like quicksort, it falls back to heapsort after depth partition
+// steps.
+func quickselect[T any](ctx context.Context, pool *workers.Pool, arr, buf []T, p int, r int, k int,
+	less func(a, b T) bool, pol policy, depth int, rng prng) {
+	for p < r && ctx.Err() == nil {
+		if depth == 0 {
+			heapSort(arr[p:r+1], less)
+			return
+		}
+		depth--
+
+		lt, gt := partitionStep(pool, arr, buf, p, r, less, pol, &rng)
+
+		switch {
+		case k < lt:
+			r = lt - 1
+		case k > gt:
+			p = gt + 1
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Test parallel quickselect, nth element and top k
+package quicksort
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+// TestSelect checks Select returns the entry at each index of the sorted array.
+func TestSelect(t *testing.T) {
+	cases := [][]int{
+		{0},
+		{1, 0},
+		{3, 0, 5, 7, 1, 6, 2, 4},
+		{1, 1, 0, 0, 1, 0, 1, 0},
+		{7, 6, 5, 4, 3, 2, 1, 0},
+	}
+
+	for _, in := range cases {
+		want := make([]int, len(in))
+		copy(want, in)
+		sort.Ints(want)
+
+		for k := range in {
+			arrIn := make([]int, len(in))
+			copy(arrIn, in)
+
+			if got := Select(arrIn, k); got != want[k] {
+				t.Errorf("Select (%v, %d) == %d, want %d", in, k, got, want[k])
+			}
+		}
+	}
+}
+
+// TestNthElement checks NthElementFuncWithOptions places the k-th entry, and
+// splits the rest of the array around it, with different options.
+func TestNthElement(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{{}, {MaxProcs: 4, PartitionCutoff: 100}, {Partition: Lomuto, Pivot: Ninther},
+		{Descending: true, Pivot: MedianOfThree, PartitionCutoff: -1}} {
+		for _, keys := range []int{2, 1 << 20} {
+			for _, n := range []int{1, 10, 1000, 1 << 14} {
+				in := make([]int, n)
+				for i := range in {
+					in[i] = r.Intn(keys)
+				}
+				want := make([]int, n)
+				copy(want, in)
+				sort.Ints(want)
+				if opts.Descending {
+					sort.Sort(sort.Reverse(sort.IntSlice(want)))
+				}
+
+				k := r.Intn(n)
+				got, err := NthElementFuncWithOptions(context.Background(), in, k,
+					func(a, b int) bool { return a < b }, opts)
+				if err != nil || got[k] != want[k] {
+					t.Errorf("NthElementFuncWithOptions (n = %d, k = %d, %+v) returned error %v or a wrong entry",
+						n, k, opts, err)
+					continue
+				}
+
+				for i, v := range got {
+					before := v < got[k]
+					if opts.Descending {
+						before = v > got[k]
+					}
+					if (i < k && !before && v != got[k]) || (i > k && before) {
+						t.Errorf("NthElementFuncWithOptions (n = %d, k = %d, %+v): %d at index %d", n, k, opts, v, i)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestTopK checks TopKFuncWithOptions returns the k smallest, or largest,
+// entries in order.
+func TestTopK(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{{}, {MaxProcs: 4, PartitionCutoff: 100}, {Descending: true}} {
+		for _, n := range []int{0, 1, 10, 1 << 14} {
+			for _, k := range []int{0, 1, n / 3, n} {
+				if k > n {
+					continue
+				}
+				in := make([]int, n)
+				for i := range in {
+					in[i] = r.Intn(n)
+				}
+				want := make([]int, n)
+				copy(want, in)
+				sort.Ints(want)
+				if opts.Descending {
+					sort.Sort(sort.Reverse(sort.IntSlice(want)))
+				}
+
+				got, err := TopKFuncWithOptions(context.Background(), in, k,
+					func(a, b int) bool { return a < b }, opts)
+				if err != nil || !reflect.DeepEqual(got, want[:k]) {
+					t.Errorf("TopKFuncWithOptions (n = %d, k = %d, %+v) == %v, %v, want %v", n, k, opts, got, err,
+						want[:k])
+				}
+			}
+		}
+	}
+
+	if got := TopK([]int{5, 3, 9, 1, 7}, 2); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("TopK ([5 3 9 1 7], 2) == %v, want [1 3]", got)
+	}
+}
+
+// TestNthElementInPlace checks NthElementFuncWithOptions does not allocate a
+// scratch buffer when PartitionCutoff is larger than the array.
+func TestNthElementInPlace(t *testing.T) {
+	n := 1 << 17
+	in := rand.New(rand.NewSource(1)).Perm(n)
+	less := func(a, b int) bool { return a < b }
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := NthElementFuncWithOptions(context.Background(), in, n/2, less,
+		Options{MaxProcs: 1, PartitionCutoff: math.MaxInt})
+	runtime.ReadMemStats(&after)
+
+	if err != nil || in[n/2] != n/2 {
+		t.Errorf("NthElementFuncWithOptions (in place) returned error %v or %d, want %d", err, in[n/2], n/2)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated >= uint64(n) {
+		t.Errorf("NthElementFuncWithOptions (in place) allocated %d bytes for %d entries", allocated, n)
+	}
+}
+
+// TestSelectContext checks SelectFuncWithOptions stops when the context is
+// done.
+func TestSelectContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+	less := func(a, b int) bool { return a < b }
+	if _, err := SelectFuncWithOptions(ctx, in, 3, less, Options{}); err != context.Canceled {
+		t.Errorf("SelectFuncWithOptions (canceled) returned error %v, want %v", err, context.Canceled)
+	}
+}
+
+// TestSelectRange checks Select, NthElement and TopK panic if k is out of
+// range.
+func TestSelectRange(t *testing.T) {
+	cases := []struct {
+		name string
+		f    func()
+	}{
+		{"Select (k = len)", func() { Select([]int{1, 2}, 2) }},
+		{"Select (empty)", func() { Select([]int{}, 0) }},
+		{"NthElement (k = -1)", func() { NthElement([]int{1, 2}, -1) }},
+		{"TopK (k = len+1)", func() { TopK([]int{1, 2}, 3) }},
+	}
+
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", c.name)
+				}
+			}()
+			c.f()
+		}()
+	}
+}
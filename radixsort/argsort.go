@@ -0,0 +1,49 @@
+package radixsort
+
+import (
+	"context"
+
+	"github.com/carlosgvaso/parallel-sort/permute"
+)
+
+// Argsort returns the permutation of indices that sorts an array of positive
+// integers of any width using the parallel radix sort algorithm.
+//
+// The entry i of the result is the index in arr of the entry at position i of
+// the sorted array. Equal entries keep their relative order, and arr is left
+// unchanged. Use permute.Apply to sort arr, or other arrays, by the result.
+// It returns ErrNegativeKey if there are negative integers in arr.
+func Argsort[T Integer](arr []T) ([]int, error) {
+	return ArgsortWithOptions(context.Background(), arr, Options{})
+}
+
+// ArgsortWithOptions is like Argsort, but it stops sorting when ctx is done,
+// and it is configured by opts.
+func ArgsortWithOptions[T Integer](ctx context.Context, arr []T, opts Options) ([]int, error) {
+	if err := checkNonNegative(arr); err != nil {
+		return nil, err
+	}
+
+	perm := permute.Identity(len(arr))
+	key := func(i int) T { return arr[i] }
+
+	return sortContext(ctx, perm, key, MaxNumDigits(arr), opts)
+}
+
+// ArgsortByKey is like Argsort, but for arrays of any type sorted by an
+// unsigned integer key.
+//
+// key returns the key of an entry. It is called from multiple goroutines at the
+// same time, and more than once for each entry, so it should be cheap.
+func ArgsortByKey[T any](arr []T, key func(T) uint64) []int {
+	perm, _ := ArgsortByKeyWithOptions(context.Background(), arr, key, Options{})
+	return perm
+}
+
+// ArgsortByKeyWithOptions is like ArgsortByKey, but it stops sorting when ctx
+// is done, and it is configured by opts.
+func ArgsortByKeyWithOptions[T any](ctx context.Context, arr []T, key func(T) uint64, opts Options) ([]int, error) {
+	perm := permute.Identity(len(arr))
+
+	return SortByKeyWithOptions(ctx, perm, func(i int) uint64 { return key(arr[i]) }, opts)
+}
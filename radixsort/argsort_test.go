@@ -0,0 +1,50 @@
+// Test parallel radix sort argsort
+package radixsort
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestArgsort(t *testing.T) {
+	cases := []struct {
+		in   []int
+		want []int
+	}{
+		{[]int{}, []int{}},
+		{[]int{30, 0, 5, 700, 1, 66, 2, 4}, []int{1, 4, 6, 7, 2, 0, 5, 3}},
+		// Equal entries keep their original order.
+		{[]int{2, 1, 2, 1, 0}, []int{4, 1, 3, 0, 2}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := Argsort(arrIn)
+		if err != nil {
+			t.Errorf("Argsort (%v) returned error %v", c.in, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Argsort (%v) == %v, want %v", c.in, got, c.want)
+		}
+		if !reflect.DeepEqual(arrIn, c.in) {
+			t.Errorf("Argsort (%v) modified the input to %v", c.in, arrIn)
+		}
+	}
+
+	if _, err := Argsort([]int{1, -2}); !errors.Is(err, ErrNegativeKey) {
+		t.Errorf("Argsort ([1 -2]) returned error %v, want %v", err, ErrNegativeKey)
+	}
+
+	type person struct {
+		name string
+		age  uint64
+	}
+	people := []person{{"carol", 41}, {"alice", 29}, {"bob", 41}}
+	got := ArgsortByKey(people, func(p person) uint64 { return p.age })
+	if want := []int{1, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsortByKey (%v, by age) == %v, want %v", people, got, want)
+	}
+}
@@ -1,10 +1,18 @@
 // Package radixsort provides a parallel radix sort implementation to sort
-// arrays of positive integers in ascending order.
+// arrays of positive integers of any width in ascending or descending order.
+//
+// Radix sort is stable: elements with equal keys keep their relative order.
+// SortStable makes this guarantee explicit for callers that depend on it.
 package radixsort
 
 import (
-	"math"
+	"context"
+	"errors"
+	"fmt"
 	"sync"
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
+	"github.com/carlosgvaso/parallel-sort/workers"
 )
 
 // NumBuckets is the number of buckets.
@@ -12,32 +20,214 @@ import (
 // Since we are sorting positive integers (e.i decimal numbers), it is 10.
 const numBuckets int = 10
 
+// Bucket traversal orders.
+var (
+	ascending  = [numBuckets]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	descending = [numBuckets]int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}
+)
+
+// Grain is the minimum number of elements each goroutine places in the buckets.
+const grain int = 1 << 12
+
+// Integer is the set of integer types radix sort can sort.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Options configures SortWithOptions.
+type Options struct {
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0 or less, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
+
+	// Descending sorts the array in descending order instead of ascending.
+	Descending bool
+}
+
+// Errors returned by SortE when the input does not meet the preconditions.
+var (
+	// ErrNegativeKey is returned when the input array has negative integers.
+	ErrNegativeKey = errors.New("radixsort: negative key")
+
+	// ErrDigitCountTooSmall is returned when k is smaller than the number of
+	// digits of the largest integer in the input array.
+	ErrDigitCountTooSmall = errors.New("radixsort: digit count too small")
+)
+
+func init() {
+	sorter.Register("radixsort", sorter.Func(func(ctx context.Context, arr []int) ([]int, error) {
+		return SortContext(ctx, arr, MaxNumDigits(arr))
+	}))
+	sorter.RegisterBaseline("radixsort", sorter.Func(func(ctx context.Context, arr []int) ([]int, error) {
+		if err := checkNonNegative(arr); err != nil {
+			return arr, err
+		}
+		return SortSequential(arr, MaxNumDigits(arr)), nil
+	}))
+}
+
 // Sort sorts an array of positive integers in ascending order using the
 // parallel most significant digit radix sort algorithm.
 //
 // arr is the positive integer input array to sort.
 // k is the number of digits of the largest integer in the input array.
 // It returns the input array sorted in ascending order.
+//
+// The input is not validated, so the result is undefined if there are negative
+// integers in arr or k is too small. Use SortE to check the input.
 func Sort(arr []int, k int) []int {
-	var wg sync.WaitGroup // Wait group to synchronize parallel goroutines
-	//var k int = 3         // Max number of digits in any array element (assumes max array entry is 999)
-	/* I ended up not needing channels, but I'm keeping this just in case.
-	// Channel needed to call radixsort()
-	var returnChan chan []int
-	*/
-
-	// Run quicksort
-	wg.Add(1)
-	arr = radixsort(arr, 1, k, &wg) // , returnChan
-	wg.Wait()
+	return SortIntegers(arr, k)
+}
+
+// SortE is like Sort, but it validates the input first.
+//
+// It returns ErrNegativeKey if there are negative integers in arr, or
+// ErrDigitCountTooSmall if k is smaller than the number of digits of the
+// largest integer in arr. In both cases, arr is left unchanged.
+func SortE(arr []int, k int) ([]int, error) {
+	return SortContext(context.Background(), arr, k)
+}
+
+// SortContext is like SortE, but it stops sorting when ctx is done.
+//
+// Cancellation is checked before each recursive call, and no more goroutines
+// are started after ctx is done. In that case, it returns ctx.Err() and the
+// array is left partially sorted.
+func SortContext(ctx context.Context, arr []int, k int) ([]int, error) {
+	return SortWithOptions(ctx, arr, k, Options{})
+}
+
+// SortWithOptions is like SortContext, but it is configured by opts.
+func SortWithOptions(ctx context.Context, arr []int, k int, opts Options) ([]int, error) {
+	return SortIntegersWithOptions(ctx, arr, k, opts)
+}
+
+// SortIntegers is like Sort, but for arrays of any integer type.
+func SortIntegers[T Integer](arr []T, k int) []T {
+	arr, _ = sortContext(context.Background(), arr, identity[T], k, Options{})
+	return arr
+}
+
+// SortIntegersWithOptions is like SortWithOptions, but for arrays of any
+// integer type.
+func SortIntegersWithOptions[T Integer](ctx context.Context, arr []T, k int, opts Options) ([]T, error) {
+	if err := checkNonNegative(arr); err != nil {
+		return arr, err
+	}
 
-	/* I ended up not needing channels, but I'm keeping this just in case.
-	// Receive the sorted array through the channel
-	arr <- returnChan
-	*/
+	if numDigits := MaxNumDigits(arr); k < numDigits {
+		return arr, fmt.Errorf("%w: k = %d, want >= %d", ErrDigitCountTooSmall, k, numDigits)
+	}
+
+	return sortContext(ctx, arr, identity[T], k, opts)
+}
+
+// SortByKey sorts an array of any type in place by an unsigned integer key
+// using the parallel radix sort algorithm.
+//
+// key returns the key of an entry. It is called from multiple goroutines at the
+// same time, and more than once for each entry, so it should be cheap. Entries
+// with equal keys keep their relative order.
+// It returns the input array sorted by key in ascending order.
+func SortByKey[T any](arr []T, key func(T) uint64) []T {
+	arr, _ = SortByKeyWithOptions(context.Background(), arr, key, Options{})
 	return arr
 }
 
+// SortStable sorts an array of any type in place by an unsigned integer key
+// using the parallel radix sort algorithm, keeping entries with equal keys in
+// their original order.
+//
+// It is the same as SortByKey, since radix sort is always stable.
+func SortStable[T any](arr []T, key func(T) uint64) []T {
+	return SortByKey(arr, key)
+}
+
+// SortStableWithOptions is like SortStable, but it stops sorting when ctx is
+// done, and it is configured by opts.
+func SortStableWithOptions[T any](ctx context.Context, arr []T, key func(T) uint64, opts Options) ([]T, error) {
+	return SortByKeyWithOptions(ctx, arr, key, opts)
+}
+
+// SortByKeyWithOptions is like SortByKey, but it stops sorting when ctx is
+// done, and it is configured by opts.
+func SortByKeyWithOptions[T any](ctx context.Context, arr []T, key func(T) uint64, opts Options) ([]T, error) {
+	// Find the largest key to get the number of digits to sort by
+	var max uint64 = 0
+	for _, v := range arr {
+		if kv := key(v); kv > max {
+			max = kv
+		}
+	}
+
+	return sortContext(ctx, arr, key, MaxNumDigits([]uint64{max}), opts)
+}
+
+// SortContext runs radixsort on the whole array without validating the input.
+//
+// key returns the integer key of each entry, and k is the number of digits of
+// the largest key.
+// It returns the array sorted, or ctx.Err() if ctx is done.
+func sortContext[T any, K Integer](ctx context.Context, arr []T, key func(T) K, k int,
+	opts Options) ([]T, error) {
+	// All keys are 0 if none of them has digits
+	if k < 1 {
+		return arr, ctx.Err()
+	}
+
+	// Digits beyond the ones of the largest value of K are always 0, so there
+	// is no need to sort by them (and their divisor would overflow K)
+	if typeDigits := maxDigits[K](); k > typeDigits {
+		k = typeDigits
+	}
+
+	var wg sync.WaitGroup                 // Wait group to synchronize parallel goroutines
+	var pool = workers.New(opts.MaxProcs) // Goroutine budget
+
+	// Scratch array to place the elements in the buckets
+	buf := make([]T, len(arr))
+
+	// Order to traverse the buckets in
+	order := ascending
+	if opts.Descending {
+		order = descending
+	}
+
+	// Run radixsort
+	pool.Go(&wg, func() {
+		radixsort(ctx, pool, arr, buf, key, &order, 1, k)
+	})
+	wg.Wait()
+
+	return arr, ctx.Err()
+}
+
+// MaxNumDigits gets number of digits of largest integer in array of positive
+// integers.
+//
+// arr is the input array of positive integers.
+// It return the number of digits of the largest integer in array
+func MaxNumDigits[T Integer](arr []T) int {
+	var k int = 0
+	var max T = 0
+
+	// Find the largest integer in array
+	for _, v := range arr {
+		if v > max {
+			max = v
+		}
+	}
+
+	// Find the number of characters of the largest integer
+	for max != 0 {
+		max /= 10
+		k++
+	}
+
+	return k
+}
+
 // Radixsort is a most significant digit radixsort implementation with
 // parallelized by goroutines to fill the buckets and at each recursive call.
 //
@@ -45,130 +235,217 @@ func Sort(arr []int, k int) []int {
 // IN-PLACE RADIX SORT ALGORITHM by Rolland He: PARADIS:
 // https://stanford.edu/~rezab/classes/cme323/S16/projects_reports/he.pdf
 //
+// The array is split in chunks, one per goroutine in the pool. Each goroutine
+// counts the elements of its chunk that go in each bucket, and then places
+// them in the buckets in buf, at the offsets given by the counts of the
+// previous chunks. This keeps the elements in each bucket in the same order
+// they were in arr, so the sort is stable.
+//
 // arr is the input array to sort.
+// buf is a scratch array with the same length as arr.
+// key returns the integer key to sort each element by.
+// order is the order to traverse the buckets in.
 // l is the current most significant digit, where l=1 is the most significant
-// digit of the largest integer in the array, and l=k-1 is the least significant
+// digit of the largest integer in the array, and l=k is the least significant
 // digit.
 // k is the maximum number of digits in the array.
-// wg is a sync.WaitGroup for synchronization of the goroutines.
-// It returns the array sorted in ascending order.
-func radixsort(arr []int, l int, k int, wg *sync.WaitGroup) []int { //, ch chan []int
-	defer wg.Done()
-
-	// Check if we got just one element in the bucket
-	if len(arr) == 1 {
-		/* I ended up not needing channels, but I'm keeping this just in case.
-		// Send the sorted array through the channel
-		ch <- arr
-		*/
-		return arr
-	}
-
-	// Wait group to syncronize concurrent bucket operations using goroutines
-	var wgBuckets sync.WaitGroup
-
-	// Buckets is an array of slices. This data structure will be written
-	// concurrently, so we need mutexes to make sure 2 or more goroutines don't
-	// write to it at the same time. Since go allows to write concurrently to
-	// different array entries, we create a mutex for each bucket.
-	var buckets [numBuckets][]int
-	var bucketLocks [numBuckets]sync.Mutex
-
-	/* I ended up not needing channels, but I'm keeping this just in case.
-	// Similarly, we create a channel for each bucket, so we can retrieve the
-	// values of the recursive calls, which are called concurrently as
-	// goroutines.
-	var bucketChans [numBuckets]chan []int
-	for i := range bucketChans {
-		wgBuckets.Add(1)
-		go func(i int) {
-			defer wgBuckets.Done()
-
-			bucketChans[i] = make(chan []int)
-		}(i)
-	}
-	wgBuckets.Wait()
-	*/
-
-	// Place the elements in the buckets concurrently
-	for _, v := range arr {
-		wgBuckets.Add(1)
-		go func(v int) {
-			defer wgBuckets.Done()
+// The array is sorted in place in the bucket order, or left unchanged if ctx is
+// done.
+func radixsort[T any, K Integer](ctx context.Context, pool *workers.Pool, arr []T, buf []T, key func(T) K,
+	order *[numBuckets]int, l int, k int) {
+	// Check if we got just one element in the bucket, or we were canceled
+	if len(arr) <= 1 || ctx.Err() != nil {
+		return
+	}
+
+	// Divisor to get the lth most significant digit
+	var div K = pow10[K](k - l)
+
+	// Split the array in chunks, and count the elements of each chunk that
+	// go in each bucket
+	chunks := workers.Chunks(len(arr), grain, pool.Size())
+	size := (len(arr) + chunks - 1) / chunks
+	counts := make([][numBuckets]int, chunks)
 
-			// Get the lth most significant digit, d, of the element. Elements with
-			// less digits than the largest element are zero-padded.
-			d := int((v / int(math.Pow10((k-1)/l))) % 10)
+	forEachChunk(pool, chunks, func(c int) {
+		lo, hi := chunkBounds(c, size, len(arr))
+		for _, v := range arr[lo:hi] {
+			counts[c][digit(key(v), div)]++
+		}
+	})
 
-			// Place element in bucket buckets[d]
-			bucketLocks[d].Lock()
-			buckets[d] = append(buckets[d], v)
-			bucketLocks[d].Unlock()
-		}(v)
+	// Turn the counts into the offset in buf where each chunk starts placing
+	// elements in each bucket, and save where each bucket starts and ends.
+	// Buckets are laid out in the traversal order, so a descending sort does
+	// not need to reverse the array afterwards.
+	var bucketStart, bucketEnd [numBuckets]int
+	var offset int = 0
+	for _, d := range order {
+		bucketStart[d] = offset
+		for c := 0; c < chunks; c++ {
+			count := counts[c][d]
+			counts[c][d] = offset
+			offset += count
+		}
+		bucketEnd[d] = offset
 	}
-	wgBuckets.Wait()
 
-	if l <= k {
+	// Place the elements in the buckets
+	forEachChunk(pool, chunks, func(c int) {
+		lo, hi := chunkBounds(c, size, len(arr))
+		for _, v := range arr[lo:hi] {
+			d := digit(key(v), div)
+			buf[counts[c][d]] = v
+			counts[c][d]++
+		}
+	})
+
+	// Replace arr elements with elements from buckets in the same order
+	pool.For(len(arr), grain, func(lo, hi int) {
+		copy(arr[lo:hi], buf[lo:hi])
+	})
+
+	if l < k && ctx.Err() == nil {
 		// Concurrent recursive call
-		for _, bucket := range buckets {
-			// Only recurse if bucket is not empty
-			if len(bucket) > 0 {
-				wgBuckets.Add(1)
-				// The bucket that we are passing is nothing but a slice that
-				// the radixsort recursive call will sort. Since radixsort()
-				// saves the sorted array back to the input array passed to it,
-				// there is no need to return/get the sorted array, because it
-				// will already be saved in the bucket.
-				go radixsort(bucket, l+1, k, &wgBuckets) //, bucketChans[i]
+		var wgBuckets sync.WaitGroup
+		for d := 0; d < numBuckets; d++ {
+			lo, hi := bucketStart[d], bucketEnd[d]
+
+			// Only recurse if bucket has more than one element
+			if hi-lo > 1 {
+				// The bucket that we are passing is nothing but a slice of arr
+				// that the radixsort recursive call will sort in place.
+				pool.Go(&wgBuckets, func() {
+					radixsort(ctx, pool, arr[lo:hi], buf[lo:hi], key, order, l+1, k)
+				})
 			}
 		}
 		wgBuckets.Wait()
+	}
+}
+
+/* Sequential */
+
+// SortSequential sorts an array of positive integers in ascending order using
+// the sequential most significant digit radix sort algorithm on a single
+// goroutine.
+//
+// It is the baseline to measure the speedup of Sort, and takes the same
+// arguments. The input is not validated.
+// It returns the input array sorted in ascending order.
+func SortSequential(arr []int, k int) []int {
+	if typeDigits := maxDigits[int](); k > typeDigits {
+		k = typeDigits
+	}
+	if k >= 1 {
+		sequentialRadixsort(arr, make([]int, len(arr)), 1, k)
+	}
+
+	return arr
+}
+
+// SequentialRadixsort is like radixsort, but it counts, places and recurses on
+// the buckets in the calling goroutine.
+func sequentialRadixsort[T Integer](arr []T, buf []T, l int, k int) {
+	if len(arr) <= 1 {
+		return
+	}
 
-		/* I ended up not needing channels, but I'm keeping this just in case.
-		// Get results. This assumes numBuckets = 10 (radix of 10).
-		// Use channels to return data from radixsort()
-		for i := 0; i < numBuckets; i++ {
-			select {
-			case arrTmp := <-bucketChans[0]:
-				buckets[0] = arrTmp
-			case arrTmp := <-bucketChans[1]:
-				buckets[1] = arrTmp
-			case arrTmp := <-bucketChans[2]:
-				buckets[2] = arrTmp
-			case arrTmp := <-bucketChans[3]:
-				buckets[3] = arrTmp
-			case arrTmp := <-bucketChans[4]:
-				buckets[4] = arrTmp
-			case arrTmp := <-bucketChans[5]:
-				buckets[5] = arrTmp
-			case arrTmp := <-bucketChans[6]:
-				buckets[6] = arrTmp
-			case arrTmp := <-bucketChans[7]:
-				buckets[7] = arrTmp
-			case arrTmp := <-bucketChans[8]:
-				buckets[8] = arrTmp
-			case arrTmp := <-bucketChans[9]:
-				buckets[9] = arrTmp
+	// Divisor to get the lth most significant digit
+	var div T = pow10[T](k - l)
+
+	// Count the elements that go in each bucket, and turn the counts into
+	// where each bucket starts
+	var starts [numBuckets + 1]int
+	for _, v := range arr {
+		starts[digit(v, div)+1]++
+	}
+	for d := 0; d < numBuckets; d++ {
+		starts[d+1] += starts[d]
+	}
+
+	// Place the elements in the buckets, and copy them back
+	next := starts
+	for _, v := range arr {
+		d := digit(v, div)
+		buf[next[d]] = v
+		next[d]++
+	}
+	copy(arr, buf)
+
+	if l < k {
+		for d := 0; d < numBuckets; d++ {
+			if lo, hi := starts[d], starts[d+1]; hi-lo > 1 {
+				sequentialRadixsort(arr[lo:hi], buf[lo:hi], l+1, k)
 			}
 		}
-		*/
 	}
+}
 
-	// Replace arr elements with elements from buckets in the same order
-	var i int = 0
-	// Iterate over all the buckets in ascendig order
-	for _, bucket := range buckets {
-		// Iterate over all the entries in a bucket in ascending order
-		for _, v := range bucket {
-			// Add entries to arr
-			arr[i] = v
-			i++
+// ForEachChunk calls fn(c) for each chunk c in [0, chunks) in parallel, and
+// waits for all the calls to return.
+func forEachChunk(pool *workers.Pool, chunks int, fn func(c int)) {
+	var wg sync.WaitGroup
+	for c := 0; c < chunks; c++ {
+		c := c
+		pool.Go(&wg, func() { fn(c) })
+	}
+	wg.Wait()
+}
+
+// ChunkBounds returns the bounds [lo, hi) of chunk c of the given size in an
+// array of length n.
+func chunkBounds(c int, size int, n int) (int, int) {
+	lo := c * size
+	hi := lo + size
+	if hi > n {
+		hi = n
+	}
+	if lo > n {
+		lo = n
+	}
+
+	return lo, hi
+}
+
+// CheckNonNegative returns ErrNegativeKey if there are negative integers in arr.
+func checkNonNegative[T Integer](arr []T) error {
+	for i, v := range arr {
+		if v < 0 {
+			return fmt.Errorf("%w: %d at index %d", ErrNegativeKey, v, i)
 		}
 	}
+	return nil
+}
 
-	/* I ended up not needing channels, but I'm keeping this just in case.
-	// Send the sorted array through the channel
-	ch <- arr
-	*/
-	return arr
+// Identity returns v. It is the key of integers sorted by their value.
+func identity[T Integer](v T) T {
+	return v
+}
+
+// Digit returns the decimal digit of v at the position given by div, where
+// div is a power of 10. Elements with less digits are zero-padded.
+func digit[T Integer](v T, div T) int {
+	return int((v / div) % 10)
+}
+
+// Pow10 returns 10**e for e >= 0.
+func pow10[T Integer](e int) T {
+	var p T = 1
+	for ; e > 0; e-- {
+		p *= 10
+	}
+
+	return p
+}
+
+// MaxDigits returns the number of digits of the largest value of T.
+func maxDigits[T Integer]() int {
+	// Set all the bits that don't make the value overflow to negative
+	var max T = 1
+	for next := max<<1 | 1; next > max; next = max<<1 | 1 {
+		max = next
+	}
+
+	return MaxNumDigits([]T{max})
 }
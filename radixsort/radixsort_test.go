@@ -2,8 +2,15 @@
 package radixsort
 
 import (
+	"context"
+	"errors"
+	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
+	"github.com/carlosgvaso/parallel-sort/sorter"
 )
 
 // TestSort checks Sort with a multitude of input arrays.
@@ -33,3 +40,184 @@ func TestSort(t *testing.T) {
 		}
 	}
 }
+
+// TestMaxNumDigits checks MaxNumDigits with a multitude of input arrays.
+func TestMaxNumDigits(t *testing.T) {
+	cases := []struct {
+		in   []int
+		want int
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, 1},
+		{[]int{7, 6, 5, 45, 3, 26, 1, 10}, 2},
+		{[]int{999, 4, 295, 666, 43, 66, 6, 576}, 3},
+	}
+
+	for _, c := range cases {
+		got := MaxNumDigits(c.in)
+
+		if got != c.want {
+			t.Errorf("MaxNumDigits (%v) == %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+// TestSortE checks SortE sorts valid input, and rejects invalid input.
+func TestSortE(t *testing.T) {
+	cases := []struct {
+		k       int
+		in      []int
+		want    []int
+		wantErr error
+	}{
+		{4, []int{1234, 999, 4, 5678, 10, 1000}, []int{4, 10, 999, 1000, 1234, 5678}, nil},
+		{5, []int{1234, 999, 4}, []int{4, 999, 1234}, nil},
+		{0, []int{0, 0, 0}, []int{0, 0, 0}, nil},
+		{3, []int{1234, 999, 4}, []int{1234, 999, 4}, ErrDigitCountTooSmall},
+		{2, []int{3, -1, 2}, []int{3, -1, 2}, ErrNegativeKey},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := SortE(arrIn, c.k)
+
+		if !errors.Is(err, c.wantErr) {
+			t.Errorf("SortE (%v, %d) returned error %v, want %v", c.in, c.k, err, c.wantErr)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortE (%v, %d) == %v, want %v", c.in, c.k, got, c.want)
+		}
+	}
+}
+
+// TestSortContext checks SortContext stops sorting when the context is done.
+func TestSortContext(t *testing.T) {
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	arrIn := make([]int, len(in))
+	copy(arrIn, in)
+	if _, err := SortContext(ctx, arrIn, 1); err != context.Canceled {
+		t.Errorf("SortContext (canceled, %v) returned error %v, want %v", in, err, context.Canceled)
+	}
+
+	copy(arrIn, in)
+	got, err := SortContext(context.Background(), arrIn, 1)
+	if err != nil {
+		t.Errorf("SortContext (%v) returned error %v", in, err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortContext (%v) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortIntegers checks SortIntegers with arrays of other integer types.
+func TestSortIntegers(t *testing.T) {
+	bytes := SortIntegers([]uint8{255, 7, 0, 128, 42, 7}, 3)
+	if want := []uint8{0, 7, 7, 42, 128, 255}; !reflect.DeepEqual(bytes, want) {
+		t.Errorf("SortIntegers ([]uint8) == %v, want %v", bytes, want)
+	}
+
+	// k larger than the number of digits of the type
+	int8s := SortIntegers([]int8{127, 3, 0, 99, 100}, 5)
+	if want := []int8{0, 3, 99, 100, 127}; !reflect.DeepEqual(int8s, want) {
+		t.Errorf("SortIntegers ([]int8) == %v, want %v", int8s, want)
+	}
+
+	uints := []uint64{1<<64 - 1, 7, 0, 1 << 63, 42}
+	got, err := SortIntegersWithOptions(context.Background(), uints, MaxNumDigits(uints), Options{})
+	if want := []uint64{0, 7, 42, 1 << 63, 1<<64 - 1}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("SortIntegersWithOptions ([]uint64) == %v, %v, want %v", got, err, want)
+	}
+
+	_, err = SortIntegersWithOptions(context.Background(), []int32{3, -2}, 1, Options{})
+	if !errors.Is(err, ErrNegativeKey) {
+		t.Errorf("SortIntegersWithOptions ([]int32{3, -2}) returned error %v, want %v", err, ErrNegativeKey)
+	}
+}
+
+// TestSortByKey checks SortByKey with an array of records.
+func TestSortByKey(t *testing.T) {
+	type record struct {
+		name string
+		id   uint64
+	}
+	in := []record{{"carol", 1003}, {"alice", 7}, {"dave", 1 << 40}, {"bob", 7}, {"erin", 0}}
+	want := []record{{"erin", 0}, {"alice", 7}, {"bob", 7}, {"carol", 1003}, {"dave", 1 << 40}}
+
+	got := SortByKey(in, func(r record) uint64 { return r.id })
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortByKey (%v, by id) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortStable checks SortStable keeps the payloads of entries with equal keys in
+// their original order.
+func TestSortStable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 10, 1000, 1 << 14} {
+		in := stabletest.Pairs(r, n, 1000)
+
+		got, err := SortStableWithOptions(context.Background(), in,
+			func(p stabletest.Pair) uint64 { return uint64(p.Key) }, Options{MaxProcs: 4})
+		if err == nil {
+			err = stabletest.Check(got, false)
+		}
+		if err != nil {
+			t.Errorf("SortStable (n = %d): %v", n, err)
+		}
+	}
+}
+
+// TestSortDescending checks SortWithOptions sorts in descending order.
+func TestSortDescending(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{7, 6, 5, 4, 3, 2, 1, 0}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4, 5}, []int{7, 6, 5, 5, 4, 3, 2, 1, 0}},
+		{[]int{42, 7, 999, 0, 130}, []int{999, 130, 42, 7, 0}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := SortWithOptions(context.Background(), arrIn, MaxNumDigits(arrIn), Options{Descending: true})
+
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortWithOptions (%v, descending) == %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+// TestSortSequential checks the sequential baseline sorts like Sort.
+func TestSortSequential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 7, 100, 1000} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(n + 1)
+		}
+		want := make([]int, n)
+		copy(want, in)
+		sort.Ints(want)
+
+		if got := SortSequential(in, MaxNumDigits(in)); !reflect.DeepEqual(got, want) {
+			t.Errorf("SortSequential (n = %d) == %v, want %v", n, got, want)
+		}
+	}
+
+	// SortSequential does not validate its input, so the registered baseline
+	// must reject negative integers itself
+	b, _ := sorter.LookupBaseline("radixsort")
+	if _, err := b.Sort(context.Background(), []int{3, -1, 2}); !errors.Is(err, ErrNegativeKey) {
+		t.Errorf("radixsort baseline: Sort ([3 -1 2]) returned error %v, want %v", err, ErrNegativeKey)
+	}
+}
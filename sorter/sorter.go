@@ -0,0 +1,118 @@
+// Package sorter provides a common interface for the parallel sorting
+// algorithms, and a registry to look them up by name.
+//
+// Each sorting package registers itself in its init function, so importing a
+// sorting package (even with a blank import) is enough to make it available:
+//
+//	import _ "github.com/carlosgvaso/parallel-sort/quicksort"
+package sorter
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Sorter is implemented by all the parallel sorting algorithms.
+type Sorter interface {
+	// Sort sorts an integer array in place in ascending order, and stops
+	// sorting when ctx is done.
+	//
+	// It returns the input array sorted, or an error if the input is not
+	// supported by the algorithm or ctx is done. Any preprocessing the
+	// algorithm needs (e.g. computing the number of digits) is done by the
+	// implementation.
+	Sort(ctx context.Context, arr []int) ([]int, error)
+}
+
+// Func is an adapter to allow the use of ordinary functions as Sorters.
+type Func func(ctx context.Context, arr []int) ([]int, error)
+
+// Sort calls f(ctx, arr).
+func (f Func) Sort(ctx context.Context, arr []int) ([]int, error) {
+	return f(ctx, arr)
+}
+
+// Sequential is an adapter to allow the use of sequential sorting functions,
+// which run to completion, as Sorters.
+type Sequential func(arr []int) []int
+
+// Sort calls f(arr). It ignores ctx, and never returns an error.
+func (f Sequential) Sort(ctx context.Context, arr []int) ([]int, error) {
+	return f(arr), nil
+}
+
+// Registry of sorters, and of their sequential baselines, by name.
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]Sorter)
+	baselines    = make(map[string]Sorter)
+)
+
+// Register makes a sorter available by the provided name.
+//
+// It panics if Register is called twice with the same name, or if s is nil.
+func Register(name string, s Sorter) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if s == nil {
+		panic("sorter: Register sorter is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("sorter: Register called twice for sorter " + name)
+	}
+	registry[name] = s
+}
+
+// RegisterBaseline makes a sequential version of the sorter with the provided
+// name available, to measure the speedup of the sorter.
+//
+// It panics if RegisterBaseline is called twice with the same name, or if s is
+// nil.
+func RegisterBaseline(name string, s Sorter) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if s == nil {
+		panic("sorter: RegisterBaseline sorter is nil")
+	}
+	if _, dup := baselines[name]; dup {
+		panic("sorter: RegisterBaseline called twice for sorter " + name)
+	}
+	baselines[name] = s
+}
+
+// Lookup returns the sorter registered with the provided name, and whether it
+// was found.
+func Lookup(name string) (Sorter, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	s, ok := registry[name]
+	return s, ok
+}
+
+// LookupBaseline returns the sequential baseline registered for the sorter with
+// the provided name, and whether it was found.
+func LookupBaseline(name string) (Sorter, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	s, ok := baselines[name]
+	return s, ok
+}
+
+// Names returns a sorted list of the names of the registered sorters.
+func Names() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
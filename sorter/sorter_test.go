@@ -0,0 +1,146 @@
+// Test sorter registry
+package sorter_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
+
+	_ "github.com/carlosgvaso/parallel-sort/bitonicsort"
+	_ "github.com/carlosgvaso/parallel-sort/bricksort"
+	_ "github.com/carlosgvaso/parallel-sort/mergesort"
+	_ "github.com/carlosgvaso/parallel-sort/quicksort"
+	_ "github.com/carlosgvaso/parallel-sort/radixsort"
+	_ "github.com/carlosgvaso/parallel-sort/timsort"
+)
+
+// TestRegisteredSorters checks all sorting packages are registered, and sort
+// arrays of any length.
+func TestRegisteredSorters(t *testing.T) {
+	names := []string{"bitonicsort", "bottomupmergesort", "bricksort", "inplacemergesort", "lomutoquicksort", "mergesort", "naturalmergesort", "quicksort", "radixsort", "timsort"}
+	in := []int{7, 6, 5, 45, 3, 26, 1, 10, 999, 4, 1234}
+	want := []int{1, 3, 4, 5, 6, 7, 10, 26, 45, 999, 1234}
+
+	for _, name := range names {
+		s, ok := sorter.Lookup(name)
+		if !ok {
+			t.Errorf("Lookup (%q) not found", name)
+			continue
+		}
+
+		arrIn := make([]int, len(in))
+		copy(arrIn, in)
+		got, err := s.Sort(context.Background(), arrIn)
+		if err != nil {
+			t.Errorf("%s: Sort (%v) returned error %v", name, in, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Sort (%v) == %v, want %v", name, in, got, want)
+		}
+		if !reflect.DeepEqual(arrIn, want) {
+			t.Errorf("%s: Sort (%v) did not sort in place: %v", name, in, arrIn)
+		}
+
+		b, ok := sorter.LookupBaseline(name)
+		if !ok {
+			t.Errorf("LookupBaseline (%q) not found", name)
+			continue
+		}
+		copy(arrIn, in)
+		if got, err := b.Sort(context.Background(), arrIn); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s baseline: Sort (%v) == %v, %v, want %v", name, in, got, err, want)
+		}
+	}
+}
+
+// TestRegisteredSortersNegative checks no registered sorter, nor baseline,
+// loses entries of arrays with negative values: they either sort them, or
+// return an error.
+func TestRegisteredSortersNegative(t *testing.T) {
+	in := []int{-1, 2, 1, -7, 0, 3, -1}
+	want := []int{-7, -1, -1, 0, 1, 2, 3}
+
+	for _, name := range sorter.Names() {
+		if strings.HasPrefix(name, "test-") {
+			continue
+		}
+		s, _ := sorter.Lookup(name)
+		b, ok := sorter.LookupBaseline(name)
+		if !ok {
+			b = s
+		}
+
+		for _, srt := range []sorter.Sorter{s, b} {
+			arrIn := make([]int, len(in))
+			copy(arrIn, in)
+			got, err := srt.Sort(context.Background(), arrIn)
+			if err == nil && !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: Sort (%v) == %v, want %v or an error", name, in, got, want)
+			}
+		}
+	}
+}
+
+// TestRegistry checks Register, Lookup and Names.
+func TestRegistry(t *testing.T) {
+	reverse := sorter.Func(func(ctx context.Context, arr []int) ([]int, error) {
+		for i, j := 0, len(arr)-1; i < j; i, j = i+1, j-1 {
+			arr[i], arr[j] = arr[j], arr[i]
+		}
+		return arr, nil
+	})
+	sorter.Register("test-reverse", reverse)
+
+	s, ok := sorter.Lookup("test-reverse")
+	if !ok {
+		t.Fatalf("Lookup (%q) not found", "test-reverse")
+	}
+	got, _ := s.Sort(context.Background(), []int{2, 1, 0})
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sort ([2 1 0]) == %v, want %v", got, want)
+	}
+
+	if _, ok := sorter.Lookup("missing"); ok {
+		t.Errorf("Lookup (%q) found, want not found", "missing")
+	}
+
+	found := false
+	for _, name := range sorter.Names() {
+		if name == "test-reverse" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Names () == %v, want it to contain %q", sorter.Names(), "test-reverse")
+	}
+}
+
+// TestRegisterBaselineDuplicate checks RegisterBaseline panics on duplicate
+// names.
+func TestRegisterBaselineDuplicate(t *testing.T) {
+	sorter.RegisterBaseline("test-dup", sorter.Sequential(func(arr []int) []int { return arr }))
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterBaseline (%q) twice did not panic", "test-dup")
+		}
+	}()
+	sorter.RegisterBaseline("test-dup", sorter.Sequential(func(arr []int) []int { return arr }))
+}
+
+// TestRegisterDuplicate checks Register panics on duplicate names.
+func TestRegisterDuplicate(t *testing.T) {
+	sorter.Register("test-dup", sorter.Func(func(ctx context.Context, arr []int) ([]int, error) { return arr, nil }))
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register (%q) twice did not panic", "test-dup")
+		}
+	}()
+	sorter.Register("test-dup", sorter.Func(func(ctx context.Context, arr []int) ([]int, error) { return arr, nil }))
+}
@@ -0,0 +1,526 @@
+package timsort
+
+import "context"
+
+const (
+	// MinMerge is the length under which arrays are sorted by binary insertion
+	// sort alone, and the bound of the minimum run length.
+	minMerge = 32
+
+	// MinGallop is the initial number of consecutive entries one run has to
+	// win in a merge before the merge switches to galloping mode.
+	minGallop = 7
+)
+
+// State holds the stack of pending runs of a Timsort, and the scratch buffer
+// of its merges.
+type state[T any] struct {
+	less func(a, b T) bool
+
+	// MinGallop adapts to the input: it grows when galloping does not pay
+	// off, and shrinks when it does.
+	minGallop int
+
+	tmp     []T   // Scratch buffer for merges
+	runBase []int // Start of each pending run
+	runLen  []int // Length of each pending run
+}
+
+// Timsort sorts s in place by the Timsort algorithm on the calling goroutine.
+//
+// It returns without merging the pending runs if ctx is done. Cancellation is
+// checked before each run, so s is always a permutation of its original
+// entries.
+func timsort[T any](ctx context.Context, s []T, less func(a, b T) bool) {
+	n := len(s)
+	if n < 2 {
+		return
+	}
+
+	// Short arrays are sorted without merges
+	if n < minMerge {
+		binaryInsertionSort(s, countRun(s, less), less)
+		return
+	}
+
+	ts := &state[T]{less: less, minGallop: minGallop}
+	minRun := minRunLength(n)
+
+	for lo := 0; lo < n; {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Find the next run, and extend it to minRun entries if it is short
+		r := countRun(s[lo:], less)
+		if r < minRun {
+			force := minRun
+			if force > n-lo {
+				force = n - lo
+			}
+			binaryInsertionSort(s[lo:lo+force], r, less)
+			r = force
+		}
+
+		ts.runBase = append(ts.runBase, lo)
+		ts.runLen = append(ts.runLen, r)
+		ts.mergeCollapse(s)
+		lo += r
+	}
+
+	ts.mergeForceCollapse(s)
+}
+
+// MinRunLength returns the minimum run length for an array of n entries: a
+// length in [minMerge/2, minMerge] such that n divided by it is a power of 2,
+// or slightly less than one, so the final merges are balanced.
+func minRunLength(n int) int {
+	r := 0
+	for n >= minMerge {
+		r |= n & 1
+		n >>= 1
+	}
+	return n + r
+}
+
+// CountRun returns the length of the run at the start of s, and reverses it if
+// it is strictly descending. Runs with equal entries are never strictly
+// descending, so reversing them keeps the sort stable.
+func countRun[T any](s []T, less func(a, b T) bool) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+
+	end := 2
+	if less(s[1], s[0]) {
+		for end < len(s) && less(s[end], s[end-1]) {
+			end++
+		}
+		reverse(s[:end])
+	} else {
+		for end < len(s) && !less(s[end], s[end-1]) {
+			end++
+		}
+	}
+
+	return end
+}
+
+// Reverse reverses s in place.
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// BinaryInsertionSort sorts s in place, given that s[:sorted] is already
+// sorted. Each entry is inserted after the last equal entry found by binary
+// search, so it is stable.
+func binaryInsertionSort[T any](s []T, sorted int, less func(a, b T) bool) {
+	if sorted == 0 {
+		sorted = 1
+	}
+
+	for i := sorted; i < len(s); i++ {
+		pivot := s[i]
+
+		lo, hi := 0, i
+		for lo < hi {
+			m := int(uint(lo+hi) >> 1)
+			if less(pivot, s[m]) {
+				hi = m
+			} else {
+				lo = m + 1
+			}
+		}
+
+		copy(s[lo+1:i+1], s[lo:i])
+		s[lo] = pivot
+	}
+}
+
+// MergeCollapse merges pending runs until the invariants of the run stack
+// hold for the top runs:
+//
+//	runLen[i-3] > runLen[i-2] + runLen[i-1]
+//	runLen[i-2] > runLen[i-1]
+//
+// so the lengths of the runs grow at least as fast as the Fibonacci numbers
+// down the stack, and the stack stays shorter than log(n) runs. The invariant
+// is also checked one run deeper, which fixes a flaw of the original Timsort.
+func (ts *state[T]) mergeCollapse(s []T) {
+	for len(ts.runLen) > 1 {
+		n := len(ts.runLen) - 2
+		l := ts.runLen
+
+		if (n > 0 && l[n-1] <= l[n]+l[n+1]) || (n > 1 && l[n-2] <= l[n-1]+l[n]) {
+			if l[n-1] < l[n+1] {
+				n--
+			}
+		} else if l[n] > l[n+1] {
+			break
+		}
+
+		ts.mergeAt(s, n)
+	}
+}
+
+// MergeForceCollapse merges all the pending runs.
+func (ts *state[T]) mergeForceCollapse(s []T) {
+	for len(ts.runLen) > 1 {
+		n := len(ts.runLen) - 2
+		if n > 0 && ts.runLen[n-1] < ts.runLen[n+1] {
+			n--
+		}
+
+		ts.mergeAt(s, n)
+	}
+}
+
+// MergeAt merges the pending runs i and i+1, which must be the second and
+// third, or the first and second runs from the top of the stack.
+func (ts *state[T]) mergeAt(s []T, i int) {
+	base, len1, len2 := ts.runBase[i], ts.runLen[i], ts.runLen[i+1]
+
+	ts.runLen[i] = len1 + len2
+	if last := len(ts.runLen) - 1; i == last-2 {
+		ts.runBase[i+1] = ts.runBase[last]
+		ts.runLen[i+1] = ts.runLen[last]
+	}
+	ts.runBase = ts.runBase[:len(ts.runBase)-1]
+	ts.runLen = ts.runLen[:len(ts.runLen)-1]
+
+	ts.merge(s[base:base+len1+len2], len1)
+}
+
+// Merge merges the sorted subarrays s[:m] and s[m:] in place. Entries of the
+// left subarray are placed first when they are equal.
+//
+// The entries at the start of the left subarray, and at the end of the right
+// subarray, that are already in place are found by galloping and skipped.
+// Then the shorter subarray is copied to the scratch buffer, and merged from
+// the same end.
+func (ts *state[T]) merge(s []T, m int) {
+	k := gallopRight(s[m], s[:m], 0, ts.less)
+	s, m = s[k:], m-k
+	if m == 0 {
+		return
+	}
+
+	len2 := gallopLeft(s[m-1], s[m:], len(s)-m-1, ts.less)
+	s = s[:m+len2]
+	if len2 == 0 {
+		return
+	}
+
+	if m <= len2 {
+		ts.mergeLo(s, m)
+	} else {
+		ts.mergeHi(s, m)
+	}
+}
+
+// Buffer returns a scratch buffer of n entries.
+func (ts *state[T]) buffer(n int) []T {
+	if cap(ts.tmp) < n {
+		ts.tmp = make([]T, n)
+	}
+	return ts.tmp[:n]
+}
+
+// MergeLo merges s[:len1] and s[len1:] from the start, copying s[:len1] to the
+// scratch buffer. It needs s[len1] to sort before s[0], and s[len1-1] to sort
+// after s[len(s)-1], which merge ensures.
+func (ts *state[T]) mergeLo(s []T, len1 int) {
+	less := ts.less
+	len2 := len(s) - len1
+	tmp := ts.buffer(len1)
+	copy(tmp, s[:len1])
+
+	c1, c2, dest := 0, len1, 0
+	s[dest] = s[c2]
+	dest, c2, len2 = dest+1, c2+1, len2-1
+	if len2 == 0 {
+		copy(s[dest:], tmp[c1:c1+len1])
+		return
+	}
+	if len1 == 1 {
+		copy(s[dest:], s[c2:c2+len2])
+		s[dest+len2] = tmp[c1]
+		return
+	}
+
+	mg := ts.minGallop
+outer:
+	for {
+		count1, count2 := 0, 0 // Number of times in a row each run won
+
+		// Merge one entry at a time until a run wins mg times in a row
+		for {
+			if less(s[c2], tmp[c1]) {
+				s[dest] = s[c2]
+				dest, c2, len2 = dest+1, c2+1, len2-1
+				count1, count2 = 0, count2+1
+				if len2 == 0 {
+					break outer
+				}
+			} else {
+				s[dest] = tmp[c1]
+				dest, c1, len1 = dest+1, c1+1, len1-1
+				count1, count2 = count1+1, 0
+				if len1 == 1 {
+					break outer
+				}
+			}
+			if count1|count2 >= mg {
+				break
+			}
+		}
+
+		// Gallop, copying whole blocks of a run, until galloping does not
+		// pay off anymore
+		for {
+			count1 = gallopRight(s[c2], tmp[c1:c1+len1], 0, less)
+			if count1 != 0 {
+				copy(s[dest:], tmp[c1:c1+count1])
+				dest, c1, len1 = dest+count1, c1+count1, len1-count1
+				if len1 <= 1 {
+					break outer
+				}
+			}
+			s[dest] = s[c2]
+			dest, c2, len2 = dest+1, c2+1, len2-1
+			if len2 == 0 {
+				break outer
+			}
+
+			count2 = gallopLeft(tmp[c1], s[c2:c2+len2], 0, less)
+			if count2 != 0 {
+				copy(s[dest:], s[c2:c2+count2])
+				dest, c2, len2 = dest+count2, c2+count2, len2-count2
+				if len2 == 0 {
+					break outer
+				}
+			}
+			s[dest] = tmp[c1]
+			dest, c1, len1 = dest+1, c1+1, len1-1
+			if len1 == 1 {
+				break outer
+			}
+
+			mg--
+			if count1 < minGallop && count2 < minGallop {
+				break
+			}
+		}
+		if mg < 0 {
+			mg = 0
+		}
+		mg += 2 // Penalize leaving galloping mode
+	}
+	if mg < 1 {
+		mg = 1
+	}
+	ts.minGallop = mg
+
+	switch len1 {
+	case 1:
+		copy(s[dest:], s[c2:c2+len2])
+		s[dest+len2] = tmp[c1]
+	case 0:
+		panic("timsort: less is not a strict weak ordering")
+	default:
+		copy(s[dest:], tmp[c1:c1+len1])
+	}
+}
+
+// MergeHi is like mergeLo, but it merges from the end, copying s[len1:] to the
+// scratch buffer.
+func (ts *state[T]) mergeHi(s []T, len1 int) {
+	less := ts.less
+	len2 := len(s) - len1
+	tmp := ts.buffer(len2)
+	copy(tmp, s[len1:])
+
+	c1, c2, dest := len1-1, len2-1, len(s)-1
+	s[dest] = s[c1]
+	dest, c1, len1 = dest-1, c1-1, len1-1
+	if len1 == 0 {
+		copy(s[dest-(len2-1):dest+1], tmp[:len2])
+		return
+	}
+	if len2 == 1 {
+		dest, c1 = dest-len1, c1-len1
+		copy(s[dest+1:dest+1+len1], s[c1+1:c1+1+len1])
+		s[dest] = tmp[c2]
+		return
+	}
+
+	mg := ts.minGallop
+outer:
+	for {
+		count1, count2 := 0, 0 // Number of times in a row each run won
+
+		// Merge one entry at a time until a run wins mg times in a row
+		for {
+			if less(tmp[c2], s[c1]) {
+				s[dest] = s[c1]
+				dest, c1, len1 = dest-1, c1-1, len1-1
+				count1, count2 = count1+1, 0
+				if len1 == 0 {
+					break outer
+				}
+			} else {
+				s[dest] = tmp[c2]
+				dest, c2, len2 = dest-1, c2-1, len2-1
+				count1, count2 = 0, count2+1
+				if len2 == 1 {
+					break outer
+				}
+			}
+			if count1|count2 >= mg {
+				break
+			}
+		}
+
+		// Gallop, copying whole blocks of a run, until galloping does not
+		// pay off anymore
+		for {
+			count1 = len1 - gallopRight(tmp[c2], s[:len1], len1-1, less)
+			if count1 != 0 {
+				dest, c1, len1 = dest-count1, c1-count1, len1-count1
+				copy(s[dest+1:dest+1+count1], s[c1+1:c1+1+count1])
+				if len1 == 0 {
+					break outer
+				}
+			}
+			s[dest] = tmp[c2]
+			dest, c2, len2 = dest-1, c2-1, len2-1
+			if len2 == 1 {
+				break outer
+			}
+
+			count2 = len2 - gallopLeft(s[c1], tmp[:len2], len2-1, less)
+			if count2 != 0 {
+				dest, c2, len2 = dest-count2, c2-count2, len2-count2
+				copy(s[dest+1:dest+1+count2], tmp[c2+1:c2+1+count2])
+				if len2 <= 1 {
+					break outer
+				}
+			}
+			s[dest] = s[c1]
+			dest, c1, len1 = dest-1, c1-1, len1-1
+			if len1 == 0 {
+				break outer
+			}
+
+			mg--
+			if count1 < minGallop && count2 < minGallop {
+				break
+			}
+		}
+		if mg < 0 {
+			mg = 0
+		}
+		mg += 2 // Penalize leaving galloping mode
+	}
+	if mg < 1 {
+		mg = 1
+	}
+	ts.minGallop = mg
+
+	switch len2 {
+	case 1:
+		dest, c1 = dest-len1, c1-len1
+		copy(s[dest+1:dest+1+len1], s[c1+1:c1+1+len1])
+		s[dest] = tmp[c2]
+	case 0:
+		panic("timsort: less is not a strict weak ordering")
+	default:
+		copy(s[dest-(len2-1):dest+1], tmp[:len2])
+	}
+}
+
+// GallopLeft returns the position in the sorted array s where key would be
+// inserted before all the entries equal to it, starting the search at hint.
+//
+// It probes s at hint, hint±1, hint±3, hint±7, ... until it brackets the
+// position, and then binary searches the bracket, so it takes O(log d)
+// comparisons if the position is d entries away from hint.
+func gallopLeft[T any](key T, s []T, hint int, less func(a, b T) bool) int {
+	lastOfs, ofs := 0, 1
+
+	if less(s[hint], key) {
+		// Gallop right until s[hint+lastOfs] < key <= s[hint+ofs]
+		maxOfs := len(s) - hint
+		for ofs < maxOfs && less(s[hint+ofs], key) {
+			lastOfs, ofs = ofs, 2*ofs+1
+		}
+		if ofs > maxOfs {
+			ofs = maxOfs
+		}
+		lastOfs, ofs = lastOfs+hint, ofs+hint
+	} else {
+		// Gallop left until s[hint-ofs] < key <= s[hint-lastOfs]
+		maxOfs := hint + 1
+		for ofs < maxOfs && !less(s[hint-ofs], key) {
+			lastOfs, ofs = ofs, 2*ofs+1
+		}
+		if ofs > maxOfs {
+			ofs = maxOfs
+		}
+		lastOfs, ofs = hint-ofs, hint-lastOfs
+	}
+
+	// Now s[lastOfs] < key <= s[ofs], so binary search in (lastOfs, ofs]
+	lastOfs++
+	for lastOfs < ofs {
+		m := lastOfs + (ofs-lastOfs)/2
+		if less(s[m], key) {
+			lastOfs = m + 1
+		} else {
+			ofs = m
+		}
+	}
+	return ofs
+}
+
+// GallopRight is like gallopLeft, but it returns the position after all the
+// entries equal to key.
+func gallopRight[T any](key T, s []T, hint int, less func(a, b T) bool) int {
+	lastOfs, ofs := 0, 1
+
+	if less(key, s[hint]) {
+		// Gallop left until s[hint-ofs] <= key < s[hint-lastOfs]
+		maxOfs := hint + 1
+		for ofs < maxOfs && less(key, s[hint-ofs]) {
+			lastOfs, ofs = ofs, 2*ofs+1
+		}
+		if ofs > maxOfs {
+			ofs = maxOfs
+		}
+		lastOfs, ofs = hint-ofs, hint-lastOfs
+	} else {
+		// Gallop right until s[hint+lastOfs] <= key < s[hint+ofs]
+		maxOfs := len(s) - hint
+		for ofs < maxOfs && !less(key, s[hint+ofs]) {
+			lastOfs, ofs = ofs, 2*ofs+1
+		}
+		if ofs > maxOfs {
+			ofs = maxOfs
+		}
+		lastOfs, ofs = lastOfs+hint, ofs+hint
+	}
+
+	// Now s[lastOfs] <= key < s[ofs], so binary search in (lastOfs, ofs]
+	lastOfs++
+	for lastOfs < ofs {
+		m := lastOfs + (ofs-lastOfs)/2
+		if less(key, s[m]) {
+			ofs = m
+		} else {
+			lastOfs = m + 1
+		}
+	}
+	return ofs
+}
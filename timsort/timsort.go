@@ -0,0 +1,182 @@
+// Package timsort provides a parallel Timsort implementation to sort arrays of
+// any ordered type.
+//
+// Timsort is an adaptive mergesort. It finds the runs already in the array,
+// extends the short ones by binary insertion sort, and merges them in an order
+// kept balanced by the invariants of a stack of pending runs. Merges switch to
+// galloping mode when one run keeps winning, so the merges of partially
+// ordered arrays take fewer comparisons than entries.
+//
+// The parallel version splits the array in one chunk per goroutine, sorts each
+// chunk by Timsort concurrently, and then merges the chunks in a tree where the
+// merges of each level run concurrently.
+//
+// Timsort is stable: equal entries keep their relative order.
+package timsort
+
+import (
+	"cmp"
+	"context"
+	"sync"
+
+	"github.com/carlosgvaso/parallel-sort/sorter"
+	"github.com/carlosgvaso/parallel-sort/workers"
+)
+
+// Grain is the minimum number of entries of each chunk sorted by a goroutine.
+const grain int = 1 << 12
+
+// Options configures SortWithOptions.
+type Options struct {
+	// MaxProcs is the maximum number of goroutines sorting at the same time.
+	// If it is 0 or less, runtime.GOMAXPROCS(0) is used.
+	MaxProcs int
+
+	// Descending sorts the array in descending order instead of ascending.
+	Descending bool
+}
+
+func init() {
+	sorter.Register("timsort", sorter.Func(SortContext))
+	sorter.RegisterBaseline("timsort", sorter.Sequential(SortSequential))
+}
+
+// Sort sorts an array in place using the parallel Timsort algorithm.
+//
+// It takes an array as an input.
+// It returns the input array sorted.
+func Sort(arr []int) []int {
+	return SortOrdered(arr)
+}
+
+// SortContext is like Sort, but it stops sorting when ctx is done.
+//
+// Cancellation is checked before each run is found and before each merge of
+// chunks. In that case, it returns ctx.Err() and the array is left partially
+// sorted.
+func SortContext(ctx context.Context, arr []int) ([]int, error) {
+	return SortWithOptions(ctx, arr, Options{})
+}
+
+// SortWithOptions is like SortContext, but it is configured by opts.
+func SortWithOptions(ctx context.Context, arr []int, opts Options) ([]int, error) {
+	return SortOrderedWithOptions(ctx, arr, opts)
+}
+
+// SortOrdered sorts an array of any ordered type in place using the parallel
+// Timsort algorithm.
+//
+// It returns the input array sorted.
+func SortOrdered[T cmp.Ordered](arr []T) []T {
+	arr, _ = SortOrderedWithOptions(context.Background(), arr, Options{})
+	return arr
+}
+
+// SortOrderedWithOptions is like SortWithOptions, but for arrays of any
+// ordered type.
+func SortOrderedWithOptions[T cmp.Ordered](ctx context.Context, arr []T, opts Options) ([]T, error) {
+	return SortFuncWithOptions(ctx, arr, cmp.Less[T], opts)
+}
+
+// SortFunc sorts an array of any type in place using the parallel Timsort
+// algorithm.
+//
+// less reports whether a sorts before b. It must be a strict weak ordering, and
+// it is called from multiple goroutines at the same time.
+// It returns the input array sorted.
+func SortFunc[T any](arr []T, less func(a, b T) bool) []T {
+	arr, _ = SortFuncWithOptions(context.Background(), arr, less, Options{})
+	return arr
+}
+
+// SortStable sorts an array of any type in place using the parallel Timsort
+// algorithm, keeping equal entries in their original order.
+//
+// It is the same as SortFunc, since Timsort is always stable.
+func SortStable[T any](arr []T, less func(a, b T) bool) []T {
+	return SortFunc(arr, less)
+}
+
+// SortStableWithOptions is like SortStable, but it stops sorting when ctx is
+// done, and it is configured by opts.
+func SortStableWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool,
+	opts Options) ([]T, error) {
+	return SortFuncWithOptions(ctx, arr, less, opts)
+}
+
+// SortFuncWithOptions is like SortWithOptions, but for arrays of any type
+// sorted by less.
+func SortFuncWithOptions[T any](ctx context.Context, arr []T, less func(a, b T) bool, opts Options) ([]T, error) {
+	// Sort in descending order by swapping the arguments of less. Runs are
+	// still merged left first, so the sort stays stable.
+	if opts.Descending {
+		ascLess := less
+		less = func(a, b T) bool { return ascLess(b, a) }
+	}
+
+	pool := workers.New(opts.MaxProcs)
+	n := len(arr)
+
+	// Split the array in chunks, and sort each of them concurrently
+	chunks := workers.Chunks(n, grain, pool.Size())
+	size := (n + chunks - 1) / chunks
+	bounds := make([]int, chunks+1)
+	for c := range bounds {
+		bounds[c] = c * size
+		if bounds[c] > n {
+			bounds[c] = n
+		}
+	}
+
+	pool.For(chunks, 1, func(lo, hi int) {
+		for c := lo; c < hi; c++ {
+			timsort(ctx, arr[bounds[c]:bounds[c+1]], less)
+		}
+	})
+	if ctx.Err() != nil {
+		return arr, ctx.Err()
+	}
+
+	mergeChunks(ctx, pool, arr, bounds, less)
+	return arr, ctx.Err()
+}
+
+/* Sequential */
+
+// SortSequential sorts an array in place using the Timsort algorithm on a
+// single goroutine.
+//
+// It is the baseline to measure the speedup of Sort.
+// It returns the input array sorted.
+func SortSequential(arr []int) []int {
+	timsort(context.Background(), arr, cmp.Less[int])
+	return arr
+}
+
+// MergeChunks merges the sorted chunks of s, which start at
+// bounds[:len(bounds)-1] relative to bounds[0].
+//
+// The chunks are merged in a balanced tree: both halves of the chunks are
+// merged concurrently, and then merged together. It returns without merging
+// if ctx is done.
+func mergeChunks[T any](ctx context.Context, pool *workers.Pool, s []T, bounds []int, less func(a, b T) bool) {
+	if len(bounds) <= 2 || ctx.Err() != nil {
+		return
+	}
+
+	m := len(bounds) / 2
+	middle := bounds[m] - bounds[0]
+
+	var wg sync.WaitGroup
+	pool.Go(&wg, func() {
+		mergeChunks(ctx, pool, s[:middle], bounds[:m+1], less)
+	})
+	mergeChunks(ctx, pool, s[middle:], bounds[m:], less)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	ts := &state[T]{less: less, minGallop: minGallop}
+	ts.merge(s, middle)
+}
@@ -0,0 +1,192 @@
+// Test parallel Timsort
+package timsort
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/carlosgvaso/parallel-sort/internal/stabletest"
+)
+
+func TestSort(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{}, []int{}},
+		{[]int{0}, []int{0}},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{7, 6, 5, 4, 3, 2, 1, 0}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[]int{1, 1, 0, 0, 1, 0, 1, 0}, []int{0, 0, 0, 0, 1, 1, 1, 1}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got := Sort(arrIn)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Sort (%v) == %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSortContext(t *testing.T) {
+	in := []int{7, 6, 5, 4, 3, 2, 1, 0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	arrIn := make([]int, len(in))
+	copy(arrIn, in)
+	if _, err := SortContext(ctx, arrIn); err != context.Canceled {
+		t.Errorf("SortContext (canceled, %v) returned error %v, want %v", in, err, context.Canceled)
+	}
+
+	copy(arrIn, in)
+	got, err := SortContext(context.Background(), arrIn)
+	if err != nil {
+		t.Errorf("SortContext (%v) returned error %v", in, err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortContext (%v) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortOrdered checks SortOrdered with arrays of other ordered types.
+func TestSortOrdered(t *testing.T) {
+	floats := SortOrdered([]float64{2.5, -1, 0, 3.25, -7.5, 2.5})
+	if want := []float64{-7.5, -1, 0, 2.5, 2.5, 3.25}; !reflect.DeepEqual(floats, want) {
+		t.Errorf("SortOrdered ([]float64) == %v, want %v", floats, want)
+	}
+
+	strs := SortOrdered([]string{"pear", "apple", "fig", "", "banana"})
+	if want := []string{"", "apple", "banana", "fig", "pear"}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("SortOrdered ([]string) == %v, want %v", strs, want)
+	}
+}
+
+// TestSortFunc checks SortFunc with an array of records.
+func TestSortFunc(t *testing.T) {
+	type record struct {
+		name string
+		age  int
+	}
+	in := []record{{"carol", 35}, {"alice", 30}, {"dave", 20}, {"bob", 25}, {"erin", 40}}
+	want := []record{{"dave", 20}, {"bob", 25}, {"alice", 30}, {"carol", 35}, {"erin", 40}}
+
+	got := SortFunc(in, func(a, b record) bool { return a.age < b.age })
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortFunc (%v, by age) == %v, want %v", in, got, want)
+	}
+}
+
+// TestSortStable checks SortStableWithOptions is stable with inputs made of
+// ascending, descending and random runs, which make the merges switch in and
+// out of galloping mode.
+func TestSortStable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{{}, {MaxProcs: 1}, {MaxProcs: 3}, {MaxProcs: 8, Descending: true}} {
+		for _, n := range []int{1, 2, 31, 32, 33, 1000, 1 << 15} {
+			for _, keys := range []int{4, 1 << 20} {
+				in := stabletest.Runs(r, n, keys, 500)
+
+				got, err := SortStableWithOptions(context.Background(), in, stabletest.Less, opts)
+				if err == nil {
+					err = stabletest.Check(got, opts.Descending)
+				}
+				if err != nil {
+					t.Errorf("SortStableWithOptions (n = %d, keys = %d, %+v): %v", n, keys, opts, err)
+				}
+			}
+		}
+	}
+}
+
+// TestMerge checks merge with runs that interleave in long blocks, so most of
+// the merge is done in galloping mode, from both ends.
+func TestMerge(t *testing.T) {
+	cases := []struct {
+		a, b []int
+	}{
+		{[]int{0, 1, 2}, []int{3, 4, 5}},
+		{[]int{3, 4, 5}, []int{0, 1, 2}},
+		{[]int{0, 5}, []int{1, 2, 3, 4}},
+		{[]int{1, 2, 3, 4}, []int{0, 5}},
+	}
+
+	// Blocks of growing length taken from each run in turn
+	var a, b []int
+	for i, v := 0, 0; i < 20; i++ {
+		for j := 0; j < 1<<(i%8); j++ {
+			if i%2 == 0 {
+				a = append(a, v)
+			} else {
+				b = append(b, v)
+			}
+			v++
+		}
+	}
+	cases = append(cases, struct{ a, b []int }{a, b}, struct{ a, b []int }{b, a},
+		struct{ a, b []int }{a[:len(a)/4], b}, struct{ a, b []int }{a, b[:len(b)/4]})
+
+	for _, c := range cases {
+		s := append(append([]int{}, c.a...), c.b...)
+		want := append([]int{}, s...)
+		sort.Ints(want)
+
+		ts := &state[int]{less: func(a, b int) bool { return a < b }, minGallop: minGallop}
+		ts.merge(s, len(c.a))
+
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("merge (%d and %d entries) == %v, want %v", len(c.a), len(c.b), s, want)
+		}
+	}
+}
+
+// TestSortDescending checks SortWithOptions sorts in descending order.
+func TestSortDescending(t *testing.T) {
+	cases := []struct {
+		in, want []int
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{7, 6, 5, 4, 3, 2, 1, 0}},
+		{[]int{3, 0, 5, 7, 1, 6, 2, 4, 5}, []int{7, 6, 5, 5, 4, 3, 2, 1, 0}},
+		{[]int{42, 7, 999, 0, 130}, []int{999, 130, 42, 7, 0}},
+	}
+
+	for _, c := range cases {
+		arrIn := make([]int, len(c.in))
+		copy(arrIn, c.in)
+
+		got, err := SortWithOptions(context.Background(), arrIn, Options{Descending: true})
+
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SortWithOptions (%v, descending) == %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+// TestSortSequential checks the sequential baseline sorts like Sort.
+func TestSortSequential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 7, 100, 1000, 1 << 14} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(n + 1)
+		}
+		want := make([]int, n)
+		copy(want, in)
+		sort.Ints(want)
+
+		if got := SortSequential(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SortSequential (n = %d) == %v, want %v", n, got, want)
+		}
+	}
+}
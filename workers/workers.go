@@ -0,0 +1,103 @@
+// Package workers provides a goroutine budget shared by the parallel sorting
+// algorithms.
+//
+// A Pool bounds the number of goroutines running at the same time. When the
+// budget is used up, work is run in the calling goroutine instead of waiting
+// for a free slot, so recursive algorithms can not deadlock on the pool.
+package workers
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Pool is a budget of goroutines. It is safe for concurrent use.
+type Pool struct {
+	tokens chan struct{} // Slots for extra goroutines
+	size   int           // Max number of goroutines running, including the caller
+}
+
+// New returns a pool that runs at most n goroutines at the same time, counting
+// the calling goroutine.
+//
+// If n is 0 or less, runtime.GOMAXPROCS(0) is used.
+func New(n int) *Pool {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+
+	return &Pool{
+		tokens: make(chan struct{}, n-1),
+		size:   n,
+	}
+}
+
+// Size returns the maximum number of goroutines the pool runs at the same time.
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Go runs f in a new goroutine if the budget allows it, or in the calling
+// goroutine otherwise.
+//
+// It calls wg.Add(1) before running f, and wg.Done() when f returns, so the
+// caller can wait for f with wg.Wait().
+func (p *Pool) Go(wg *sync.WaitGroup, f func()) {
+	wg.Add(1)
+
+	select {
+	case p.tokens <- struct{}{}:
+		go func() {
+			defer wg.Done()
+			defer func() { <-p.tokens }()
+			f()
+		}()
+	default:
+		defer wg.Done()
+		f()
+	}
+}
+
+// For splits [0, n) in contiguous chunks of at least grain entries, one chunk
+// per goroutine in the pool at most, and calls fn(lo, hi) for each chunk
+// [lo, hi) in parallel.
+//
+// It returns when all the calls have returned.
+func (p *Pool) For(n int, grain int, fn func(lo, hi int)) {
+	if n <= 0 {
+		return
+	}
+
+	chunks := Chunks(n, grain, p.size)
+	size := (n + chunks - 1) / chunks
+
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += size {
+		hi := lo + size
+		if hi > n {
+			hi = n
+		}
+
+		lo := lo
+		p.Go(&wg, func() { fn(lo, hi) })
+	}
+	wg.Wait()
+}
+
+// Chunks returns the number of chunks of at least grain entries to split n
+// entries in, using at most max chunks. It returns at least 1.
+func Chunks(n int, grain int, max int) int {
+	if grain < 1 {
+		grain = 1
+	}
+
+	chunks := n / grain
+	if chunks > max {
+		chunks = max
+	}
+	if chunks < 1 {
+		chunks = 1
+	}
+
+	return chunks
+}
@@ -0,0 +1,96 @@
+// Test goroutine budget
+package workers
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestGo checks Go never runs more goroutines than the pool size.
+func TestGo(t *testing.T) {
+	for _, size := range []int{1, 2, 4} {
+		p := New(size)
+		var wg sync.WaitGroup
+		var running, maxRunning int32
+
+		var recurse func(depth int)
+		recurse = func(depth int) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			if depth > 0 {
+				atomic.AddInt32(&running, -1)
+				p.Go(&wg, func() { recurse(depth - 1) })
+				p.Go(&wg, func() { recurse(depth - 1) })
+				atomic.AddInt32(&running, 1)
+			}
+			runtime.Gosched()
+			atomic.AddInt32(&running, -1)
+		}
+
+		p.Go(&wg, func() { recurse(8) })
+		wg.Wait()
+
+		if int(maxRunning) > size {
+			t.Errorf("New (%d): %d goroutines ran at the same time", size, maxRunning)
+		}
+	}
+}
+
+// TestFor checks For calls fn once for each entry.
+func TestFor(t *testing.T) {
+	cases := []struct {
+		size, n, grain int
+	}{
+		{1, 10, 1},
+		{4, 10, 1},
+		{4, 10, 3},
+		{4, 3, 100},
+		{8, 1000, 16},
+		{4, 0, 1},
+	}
+
+	for _, c := range cases {
+		counts := make([]int32, c.n)
+
+		New(c.size).For(c.n, c.grain, func(lo, hi int) {
+			for i := lo; i < hi; i++ {
+				atomic.AddInt32(&counts[i], 1)
+			}
+		})
+
+		for i, count := range counts {
+			if count != 1 {
+				t.Errorf("For (%d, %d) with size %d: entry %d visited %d times, want 1",
+					c.n, c.grain, c.size, i, count)
+			}
+		}
+	}
+}
+
+// TestChunks checks Chunks with a multitude of inputs.
+func TestChunks(t *testing.T) {
+	cases := []struct {
+		n, grain, max, want int
+	}{
+		{100, 10, 4, 4},
+		{100, 50, 4, 2},
+		{10, 100, 4, 1},
+		{0, 1, 4, 1},
+		{10, 0, 4, 4},
+	}
+
+	for _, c := range cases {
+		got := Chunks(c.n, c.grain, c.max)
+
+		if got != c.want {
+			t.Errorf("Chunks (%d, %d, %d) == %d, want %d", c.n, c.grain, c.max, got, c.want)
+		}
+	}
+}